@@ -0,0 +1,223 @@
+package ics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	duration "github.com/channelmeter/iso8601duration"
+)
+
+// Alarm is a VALARM of an event , a reminder triggered relative to the start or the end
+// of the event or at an exact time
+type Alarm struct {
+	action      string
+	trigger     time.Duration
+	related     string
+	triggerTime time.Time
+	duration    time.Duration
+	repeat      int
+	summary     string
+	description string
+	attendees   []*Attendee
+	propertySet
+}
+
+func NewAlarm() *Alarm {
+	a := new(Alarm)
+	a.related = "START"
+	a.attendees = []*Attendee{}
+	a.properties = []*Property{}
+	return a
+}
+
+// sets the action like AUDIO , DISPLAY or EMAIL
+func (a *Alarm) SetAction(action string) *Alarm {
+	a.action = action
+	return a
+}
+
+func (a *Alarm) GetAction() string {
+	return a.action
+}
+
+// sets the offset of the alarm from the start or the end of the event , negative for the alarms before it
+func (a *Alarm) SetTrigger(trigger time.Duration) *Alarm {
+	a.trigger = trigger
+	return a
+}
+
+func (a *Alarm) GetTrigger() time.Duration {
+	return a.trigger
+}
+
+// sets if the trigger is relative to the START or the END of the event
+func (a *Alarm) SetTriggerRelated(related string) *Alarm {
+	a.related = related
+	return a
+}
+
+func (a *Alarm) GetTriggerRelated() string {
+	return a.related
+}
+
+// sets the exact time of the alarm , the relative trigger is not used when it is set
+func (a *Alarm) SetTriggerTime(t time.Time) *Alarm {
+	a.triggerTime = t
+	return a
+}
+
+func (a *Alarm) GetTriggerTime() time.Time {
+	return a.triggerTime
+}
+
+// reports if the alarm is triggered at an exact time
+func (a *Alarm) IsAbsolute() bool {
+	return !a.triggerTime.IsZero()
+}
+
+// sets the delay between the repeats of the alarm
+func (a *Alarm) SetDuration(d time.Duration) *Alarm {
+	a.duration = d
+	return a
+}
+
+func (a *Alarm) GetDuration() time.Duration {
+	return a.duration
+}
+
+// sets how many times the alarm is repeated after the first one
+func (a *Alarm) SetRepeat(repeat int) *Alarm {
+	a.repeat = repeat
+	return a
+}
+
+func (a *Alarm) GetRepeat() int {
+	return a.repeat
+}
+
+func (a *Alarm) SetSummary(summary string) *Alarm {
+	a.summary = summary
+	return a
+}
+
+func (a *Alarm) GetSummary() string {
+	return a.summary
+}
+
+func (a *Alarm) SetDescription(description string) *Alarm {
+	a.description = description
+	return a
+}
+
+func (a *Alarm) GetDescription() string {
+	return a.description
+}
+
+// adds a recipient of the EMAIL alarm
+func (a *Alarm) SetAttendee(attendee *Attendee) *Alarm {
+	a.attendees = append(a.attendees, attendee)
+	return a
+}
+
+func (a *Alarm) SetAttendees(attendees []*Attendee) *Alarm {
+	a.attendees = append(a.attendees, attendees...)
+	return a
+}
+
+func (a *Alarm) GetAttendees() []*Attendee {
+	return a.attendees
+}
+
+// returns the times when the alarm is triggered for the event , the first one and its repeats
+func (a *Alarm) TriggerTimes(e *Event) []time.Time {
+	first := a.triggerTime
+	if !a.IsAbsolute() {
+		first = e.GetStart()
+		if a.related == "END" && !e.GetEnd().IsZero() {
+			first = e.GetEnd()
+		}
+		first = first.Add(a.trigger)
+	}
+
+	times := []time.Time{first}
+	if a.duration > 0 {
+		for i := 1; i <= a.repeat; i++ {
+			times = append(times, first.Add(time.Duration(i)*a.duration))
+		}
+	}
+	return times
+}
+
+// adds a raw property , the unknown and X- properties are written back as they are
+func (a *Alarm) SetProperty(p *Property) *Alarm {
+	a.appendProperty(p)
+	return a
+}
+
+// replaces all the raw properties
+func (a *Alarm) SetProperties(properties []*Property) *Alarm {
+	a.replaceProperties(properties)
+	return a
+}
+
+func (a *Alarm) String() string {
+	if a.IsAbsolute() {
+		return fmt.Sprintf("Alarm(%s) at %s", a.GetAction(), a.GetTriggerTime().Format(YmdHis))
+	}
+	return fmt.Sprintf("Alarm(%s) %s from the %s", a.GetAction(), a.GetTrigger(), strings.ToLower(a.GetTriggerRelated()))
+}
+
+// parses signed DURATION value like -PT15M
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	parsed, err := duration.FromString(value)
+	if err != nil {
+		return 0, err
+	}
+	return sign * parsed.ToDuration(), nil
+}
+
+// formats the duration as DURATION value like -PT15M or P1DT2H
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+
+	day := 24 * time.Hour
+	days := d / day
+	if days > 0 {
+		if days%7 == 0 && d%day == 0 {
+			return b.String() + strconv.Itoa(int(days/7)) + "W"
+		}
+		b.WriteString(strconv.Itoa(int(days)) + "D")
+		d -= days * day
+	}
+	if d == 0 && days > 0 {
+		return b.String()
+	}
+
+	b.WriteByte('T')
+	hours, minutes, seconds := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+	if hours > 0 {
+		b.WriteString(strconv.Itoa(int(hours)) + "H")
+	}
+	if minutes > 0 {
+		b.WriteString(strconv.Itoa(int(minutes)) + "M")
+	}
+	if seconds > 0 || (hours == 0 && minutes == 0) {
+		b.WriteString(strconv.Itoa(int(seconds)) + "S")
+	}
+	return b.String()
+}
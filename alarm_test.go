@@ -0,0 +1,110 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+const remindersCal = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"DTSTART:20190603T100000Z\r\n" +
+	"DTEND:20190603T103000Z\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=3\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DESCRIPTION:Standup in 15 minutes\r\n" +
+	"END:VALARM\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:EMAIL\r\n" +
+	"TRIGGER;RELATED=END:PT0S\r\n" +
+	"SUMMARY:Standup is over\r\n" +
+	"DESCRIPTION:Write the notes\r\n" +
+	"ATTENDEE:mailto:lead@example.com\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:deadline\r\n" +
+	"DTSTART:20190603T120000Z\r\n" +
+	"SUMMARY:Deadline\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:AUDIO\r\n" +
+	"TRIGGER;VALUE=DATE-TIME:20190603T080000Z\r\n" +
+	"DURATION:PT5M\r\n" +
+	"REPEAT:1\r\n" +
+	"END:VALARM\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:soon\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseAlarms(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(remindersCal))
+
+	standup, err := cal.GetEventByImportedID("standup")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+	alarms := standup.GetAlarms()
+	if len(alarms) != 2 {
+		t.Fatalf("Expected 2 alarms, got %d", len(alarms))
+	}
+	if alarms[0].GetAction() != "DISPLAY" || alarms[0].GetTrigger() != -15*time.Minute || alarms[0].GetTriggerRelated() != "START" {
+		t.Errorf("Unexpected alarm %s", alarms[0])
+	}
+	if alarms[1].GetTriggerRelated() != "END" || len(alarms[1].GetAttendees()) != 1 || alarms[1].GetAttendees()[0].GetEmail() != "lead@example.com" {
+		t.Errorf("Unexpected alarm %s with attendees %v", alarms[1], alarms[1].GetAttendees())
+	}
+	if at := alarms[1].TriggerTimes(standup); len(at) != 1 || !at[0].Equal(standup.GetEnd()) {
+		t.Errorf("Expected the alarm at the end of the event, got %v", at)
+	}
+
+	deadline, _ := cal.GetEventByImportedID("deadline")
+	if len(deadline.GetAlarms()) != 1 {
+		t.Fatalf("Expected the alarm with invalid trigger to be skipped, got %v", deadline.GetAlarms())
+	}
+	at := deadline.GetAlarms()[0].TriggerTimes(deadline)
+	if len(at) != 2 || at[0] != time.Date(2019, 6, 3, 8, 0, 0, 0, time.UTC) || at[1].Sub(at[0]) != 5*time.Minute {
+		t.Errorf("Unexpected trigger times %v", at)
+	}
+	if errs := cal.GetErrors(); len(errs) != 1 || errs[0].GetProperty() != "TRIGGER" {
+		t.Errorf("Expected error for the invalid trigger, got %v", errs)
+	}
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	parsed := loadSingleCalendar(t, written)
+	rewritten, _ := parsed.GetEventByImportedID("deadline")
+	if rewritten == nil || len(rewritten.GetAlarms()) != 1 || rewritten.GetAlarms()[0].GetRepeat() != 1 || !rewritten.GetAlarms()[0].GetTriggerTime().Equal(at[0]) {
+		t.Errorf("Expected the alarm to survive writing , got %v", rewritten)
+	}
+	rewritten, _ = parsed.GetEventByImportedID("standup")
+	if rewritten == nil || len(rewritten.GetAlarms()) != 2 || rewritten.GetAlarms()[0].GetTrigger() != -15*time.Minute || rewritten.GetAlarms()[1].GetTriggerRelated() != "END" {
+		t.Errorf("Expected the alarms to survive writing , got %v", rewritten)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	durations := map[time.Duration]string{
+		0:                             "PT0S",
+		-15 * time.Minute:             "-PT15M",
+		26*time.Hour + 30*time.Second: "P1DT2H30S",
+		48 * time.Hour:                "P2D",
+		14 * 24 * time.Hour:           "P2W",
+		time.Hour + 90*time.Second:    "PT1H1M30S",
+	}
+	for d, expected := range durations {
+		if got := formatDuration(d); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, d, got)
+		}
+		if parsed, err := parseDuration(expected); err != nil || parsed != d {
+			t.Errorf("Expected %s for %s, got %s ( %v )", d, expected, parsed, err)
+		}
+	}
+}
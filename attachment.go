@@ -0,0 +1,172 @@
+package ics
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Attachment is an ATTACH property , a link to a document or the document itself inline
+type Attachment struct {
+	uri      string
+	data     string
+	fmtType  string
+	filename string
+	// the inline content was written to the writer of the parser instead of being kept
+	streamed bool
+	size     int64
+}
+
+// creates new attachment that links to the uri
+func NewAttachmentURI(uri string) *Attachment {
+	return &Attachment{uri: uri}
+}
+
+// creates new attachment with the inline content
+func NewAttachmentData(data []byte) *Attachment {
+	return &Attachment{data: base64.StdEncoding.EncodeToString(data)}
+}
+
+// returns the uri of the linked document , empty for the inline ones
+func (a *Attachment) GetURI() string {
+	return a.uri
+}
+
+// reports if the content is inline
+func (a *Attachment) IsBinary() bool {
+	return a.uri == ""
+}
+
+// reports if the inline content was streamed to the writer set with Parser.SetAttachmentWriter ,
+// the content of such attachment is not kept
+func (a *Attachment) IsStreamed() bool {
+	return a.streamed
+}
+
+// returns the decoded inline content
+func (a *Attachment) GetData() ([]byte, error) {
+	if !a.IsBinary() {
+		return nil, errors.New("The attachment is a link to " + a.uri)
+	}
+	if a.streamed {
+		return nil, errors.New("The attachment content was streamed to the attachment writer")
+	}
+	// some producers fold the base64 with white space
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(a.data), ""))
+}
+
+// writes the decoded inline content to w without decoding all of it in memory
+func (a *Attachment) WriteTo(w io.Writer) (int64, error) {
+	if !a.IsBinary() {
+		return 0, errors.New("The attachment is a link to " + a.uri)
+	}
+	if a.streamed {
+		return 0, errors.New("The attachment content was streamed to the attachment writer")
+	}
+	dw := &base64Writer{dst: w}
+	if _, err := io.WriteString(dw, a.data); err != nil {
+		return dw.size, err
+	}
+	err := dw.flush()
+	return dw.size, err
+}
+
+// returns the size of the decoded inline content , 0 for the links
+func (a *Attachment) GetSize() int64 {
+	if a.streamed || !a.IsBinary() {
+		return a.size
+	}
+	data, _ := a.GetData()
+	return int64(len(data))
+}
+
+// sets the media type like application/pdf
+func (a *Attachment) SetFmtType(fmtType string) *Attachment {
+	a.fmtType = fmtType
+	return a
+}
+
+func (a *Attachment) GetFmtType() string {
+	return a.fmtType
+}
+
+// sets the name of the attached file
+func (a *Attachment) SetFilename(filename string) *Attachment {
+	a.filename = filename
+	return a
+}
+
+func (a *Attachment) GetFilename() string {
+	return a.filename
+}
+
+// base64Writer decodes the base64 text written to it and writes the content to dst.
+// After an error the rest of the text is ignored , so the parser can read the property to the end
+type base64Writer struct {
+	dst io.Writer
+	// the encoded text that is not a whole base64 quantum yet
+	pending []byte
+	size    int64
+	err     error
+	// the attachment which content is written , set for the streamed attachments
+	attachment *Attachment
+}
+
+func (w *base64Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+	for _, b := range p {
+		// some producers fold the base64 with white space
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			w.pending = append(w.pending, b)
+		}
+	}
+	whole := len(w.pending) / 4 * 4
+	w.decode(w.pending[:whole])
+	w.pending = append(w.pending[:0], w.pending[whole:]...)
+	return len(p), nil
+}
+
+// decodes the encoded text , its length is a multiple of 4
+func (w *base64Writer) decode(encoded []byte) {
+	if len(encoded) == 0 || w.err != nil {
+		return
+	}
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(decoded, encoded)
+	if err != nil {
+		w.err = err
+		return
+	}
+	written, err := w.dst.Write(decoded[:n])
+	w.size += int64(written)
+	if err != nil {
+		w.err = err
+	}
+}
+
+// decodes the rest of the text , the missing padding is accepted
+func (w *base64Writer) flush() error {
+	if len(w.pending)%4 != 0 {
+		w.pending = append(w.pending, strings.Repeat("=", 4-len(w.pending)%4)...)
+	}
+	w.decode(w.pending)
+	w.pending = nil
+	return w.err
+}
+
+// decodes the rest of the text and closes dst when it is an io.Closer
+func (w *base64Writer) Close() error {
+	w.flush()
+	if closer, ok := w.dst.(io.Closer); ok {
+		if err := closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	if w.attachment != nil {
+		w.attachment.size = w.size
+	}
+	return w.err
+}
@@ -0,0 +1,174 @@
+package ics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// returns calendar with an agenda of the size inline , folded like the producers do
+func agendaCal(agenda []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(agenda)
+	folded := []string{}
+	for len(encoded) > 60 {
+		folded = append(folded, encoded[:60])
+		encoded = encoded[60:]
+	}
+	folded = append(folded, encoded)
+
+	return []byte("BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:review\r\n" +
+		"DTSTART:20190603T090000Z\r\n" +
+		"SUMMARY:Quarterly review\r\n" +
+		"ATTACH;FMTTYPE=text/plain;X-FILENAME=notes.txt;ENCODING=BASE64;VALUE=BINARY:" + base64.StdEncoding.EncodeToString([]byte("short notes")) + "\r\n" +
+		"ATTACH;ENCODING=BASE64;VALUE=BINARY;FMTTYPE=application/pdf;FILENAME=\"agenda; Q2.pdf\":" + strings.Join(folded, "\r\n ") + "\r\n" +
+		"ATTACH;FMTTYPE=text/html:https://example.com/minutes.html\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n")
+}
+
+func TestParseAttachments(t *testing.T) {
+	agenda := bytes.Repeat([]byte("%PDF agenda of the quarterly review\n"), 100)
+	event, err := loadSingleCalendar(t, agendaCal(agenda)).GetEventByImportedID("review")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+
+	attachments := event.GetAttachments()
+	if len(attachments) != 3 {
+		t.Fatalf("Expected 3 attachments, found %d", len(attachments))
+	}
+	if data, err := attachments[0].GetData(); err != nil || string(data) != "short notes" || attachments[0].GetFilename() != "notes.txt" {
+		t.Errorf("Unexpected notes %q %q with error %v", data, attachments[0].GetFilename(), err)
+	}
+	if data, err := attachments[1].GetData(); err != nil || !bytes.Equal(data, agenda) || attachments[1].GetSize() != int64(len(agenda)) {
+		t.Errorf("Unexpected agenda of %d bytes with error %v", len(data), err)
+	}
+	if attachments[1].GetFilename() != "agenda; Q2.pdf" || attachments[1].GetFmtType() != "application/pdf" || attachments[1].IsStreamed() {
+		t.Errorf("Unexpected agenda %q %q", attachments[1].GetFilename(), attachments[1].GetFmtType())
+	}
+	if attachments[2].IsBinary() || attachments[2].GetURI() != "https://example.com/minutes.html" {
+		t.Errorf("Unexpected link %q", attachments[2].GetURI())
+	}
+	var buf bytes.Buffer
+	if n, err := attachments[1].WriteTo(&buf); err != nil || n != int64(len(agenda)) || !bytes.Equal(buf.Bytes(), agenda) {
+		t.Errorf("Unexpected %d bytes written with error %v", n, err)
+	}
+
+	written, err := event.GetCalendar().MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	rewritten, _ := loadSingleCalendar(t, written).GetEventByImportedID("review")
+	if len(rewritten.GetAttachments()) != 3 || rewritten.GetAttachments()[1].GetFilename() != "agenda; Q2.pdf" {
+		t.Errorf("Unexpected attachments after writing\n%s", written)
+	}
+	if data, _ := rewritten.GetAttachments()[1].GetData(); !bytes.Equal(data, agenda) {
+		t.Errorf("Unexpected agenda of %d bytes after writing", len(data))
+	}
+}
+
+// closeBuffer is a buffer that records if it was closed
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestStreamAttachments(t *testing.T) {
+	agenda := bytes.Repeat([]byte("%PDF agenda of the quarterly review\n"), 100)
+	streams := map[string]*closeBuffer{}
+	parser := New().SetAttachmentWriter(1024, func(a *Attachment) (io.Writer, error) {
+		buf := new(closeBuffer)
+		streams[a.GetFilename()] = buf
+		return buf, nil
+	})
+	if err := parser.LoadReader(bytes.NewReader(agendaCal(agenda))); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	calendars, _ := parser.GetCalendars()
+	event, _ := calendars[0].GetEventByImportedID("review")
+
+	attachments := event.GetAttachments()
+	if len(attachments) != 3 || len(streams) != 1 || streams["agenda; Q2.pdf"] == nil {
+		t.Fatalf("Expected only the agenda streamed, found %d attachments and %d streams", len(attachments), len(streams))
+	}
+	if attachments[0].IsStreamed() || !attachments[1].IsStreamed() || attachments[2].IsStreamed() {
+		t.Errorf("Expected only the agenda streamed")
+	}
+	if !bytes.Equal(streams["agenda; Q2.pdf"].Bytes(), agenda) || !streams["agenda; Q2.pdf"].closed {
+		t.Errorf("Unexpected agenda of %d bytes streamed", streams["agenda; Q2.pdf"].Len())
+	}
+	if attachments[1].GetSize() != int64(len(agenda)) || attachments[1].GetFmtType() != "application/pdf" {
+		t.Errorf("Unexpected agenda of %d bytes and type %q", attachments[1].GetSize(), attachments[1].GetFmtType())
+	}
+	if _, err := attachments[1].GetData(); err == nil {
+		t.Errorf("Expected error for the data of streamed attachment")
+	}
+	if event.GetSummary() != "Quarterly review" || len(calendars[0].GetErrors()) != 0 {
+		t.Errorf("Unexpected summary %q and errors %v", event.GetSummary(), calendars[0].GetErrors())
+	}
+
+	failing := New().SetAttachmentWriter(1024, func(a *Attachment) (io.Writer, error) {
+		return nil, errors.New("disk full")
+	})
+	if err := failing.LoadReader(bytes.NewReader(agendaCal(agenda))); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	calendars, _ = failing.GetCalendars()
+	if errs := calendars[0].GetErrors(); len(errs) != 1 || errs[0].GetProperty() != "ATTACH" {
+		t.Errorf("Expected error for the agenda , found %v", errs)
+	}
+	if event, _ := calendars[0].GetEventByImportedID("review"); len(event.GetAttachments()) != 3 {
+		t.Errorf("Expected the attachments after the failed stream")
+	}
+}
+
+func TestStreamAttachmentsWithLongParameters(t *testing.T) {
+	agenda := bytes.Repeat([]byte("agenda of the quarterly review\n"), 20)
+	encoded := base64.StdEncoding.EncodeToString(agenda)
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:review\r\n" +
+		"DTSTART:20190603T090000Z\r\n" +
+		"ATTACH;FMTTYPE=text/plain;X-FILENAME=notes.txt;ENCODING=BASE64;VALUE=BINARY:" + base64.StdEncoding.EncodeToString([]byte("short notes")) + "\r\n" +
+		// the parameters are folded and longer than the limit
+		"ATTACH;ENCODING=BASE64;VALUE=BINARY;FMTTYPE=application/vnd.openxmlformats-offi\r\n" +
+		" cedocument.wordprocessingml.document;X-FILENAME=Quarterly review agenda for the\r\n" +
+		"  whole team.docx:" + encoded[:40] + "\r\n" +
+		" " + encoded[40:] + "\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	streams := map[string]*closeBuffer{}
+	parser := New(WithAttachmentWriter(64, func(a *Attachment) (io.Writer, error) {
+		buf := new(closeBuffer)
+		streams[a.GetFilename()] = buf
+		return buf, nil
+	}))
+	if err := parser.LoadReader(strings.NewReader(data)); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	calendars, _ := parser.GetCalendars()
+	event, _ := calendars[0].GetEventByImportedID("review")
+
+	attachments := event.GetAttachments()
+	filename := "Quarterly review agenda for the whole team.docx"
+	if len(attachments) != 2 || len(streams) != 1 || streams[filename] == nil {
+		t.Fatalf("Expected only the agenda streamed, found %d attachments and streams %v", len(attachments), streams)
+	}
+	if attachments[0].IsStreamed() || !attachments[1].IsStreamed() {
+		t.Errorf("Expected only the agenda streamed")
+	}
+	if !bytes.Equal(streams[filename].Bytes(), agenda) || attachments[1].GetFmtType() != "application/vnd.openxmlformats-officedocument.wordprocessingml.document" {
+		t.Errorf("Unexpected agenda of %d bytes and type %q", streams[filename].Len(), attachments[1].GetFmtType())
+	}
+}
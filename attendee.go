@@ -4,12 +4,55 @@ import (
 	"fmt"
 )
 
+// Role is the ROLE of an attendee in the event
+type Role string
+
+const (
+	RoleChair          Role = "CHAIR"
+	RoleReqParticipant Role = "REQ-PARTICIPANT"
+	RoleOptParticipant Role = "OPT-PARTICIPANT"
+	RoleNonParticipant Role = "NON-PARTICIPANT"
+)
+
+// PartStat is the PARTSTAT , the participation status of an attendee
+type PartStat string
+
+const (
+	PartStatNeedsAction PartStat = "NEEDS-ACTION"
+	PartStatAccepted    PartStat = "ACCEPTED"
+	PartStatDeclined    PartStat = "DECLINED"
+	PartStatTentative   PartStat = "TENTATIVE"
+	PartStatDelegated   PartStat = "DELEGATED"
+	PartStatCompleted   PartStat = "COMPLETED"
+	PartStatInProcess   PartStat = "IN-PROCESS"
+)
+
+// CUType is the CUTYPE , the kind of the calendar user
+type CUType string
+
+const (
+	CUTypeIndividual CUType = "INDIVIDUAL"
+	CUTypeGroup      CUType = "GROUP"
+	CUTypeResource   CUType = "RESOURCE"
+	CUTypeRoom       CUType = "ROOM"
+	CUTypeUnknown    CUType = "UNKNOWN"
+)
+
+// Attendee is an ATTENDEE or ORGANIZER with its parameters. The calendar addresses
+// are kept as emails without the mailto: prefix
 type Attendee struct {
-	name   string
-	email  string
-	status string
-	role   string
-	cutype string
+	name          string
+	email         string
+	status        PartStat
+	role          Role
+	cutype        CUType
+	rsvp          bool
+	delegatedTo   []string
+	delegatedFrom []string
+	member        []string
+	sentBy        string
+	dir           string
+	language      string
 }
 
 func NewAttendee() *Attendee {
@@ -35,33 +78,103 @@ func (a *Attendee) GetEmail() string {
 	return a.email
 }
 
-func (a *Attendee) SetStatus(s string) *Attendee {
+func (a *Attendee) SetStatus(s PartStat) *Attendee {
 	a.status = s
 	return a
 }
 
-func (a *Attendee) GetStatus() string {
+func (a *Attendee) GetStatus() PartStat {
 	return a.status
 }
 
-func (a *Attendee) SetRole(r string) *Attendee {
+func (a *Attendee) SetRole(r Role) *Attendee {
 	a.role = r
 	return a
 }
 
-func (a *Attendee) GetRole() string {
+func (a *Attendee) GetRole() Role {
 	return a.role
 }
 
-func (a *Attendee) SetType(ct string) *Attendee {
+func (a *Attendee) SetType(ct CUType) *Attendee {
 	a.cutype = ct
 	return a
 }
 
-func (a *Attendee) GetType() string {
+func (a *Attendee) GetType() CUType {
 	return a.cutype
 }
 
+// sets if a reply is expected from the attendee
+func (a *Attendee) SetRSVP(rsvp bool) *Attendee {
+	a.rsvp = rsvp
+	return a
+}
+
+func (a *Attendee) GetRSVP() bool {
+	return a.rsvp
+}
+
+// sets the emails of the users the attendee delegated the participation to
+func (a *Attendee) SetDelegatedTo(emails []string) *Attendee {
+	a.delegatedTo = emails
+	return a
+}
+
+func (a *Attendee) GetDelegatedTo() []string {
+	return a.delegatedTo
+}
+
+// sets the emails of the users that delegated the participation to the attendee
+func (a *Attendee) SetDelegatedFrom(emails []string) *Attendee {
+	a.delegatedFrom = emails
+	return a
+}
+
+func (a *Attendee) GetDelegatedFrom() []string {
+	return a.delegatedFrom
+}
+
+// sets the emails of the groups the attendee is member of
+func (a *Attendee) SetMember(emails []string) *Attendee {
+	a.member = emails
+	return a
+}
+
+func (a *Attendee) GetMember() []string {
+	return a.member
+}
+
+// sets the email of the user that acts on behalf of the attendee
+func (a *Attendee) SetSentBy(email string) *Attendee {
+	a.sentBy = email
+	return a
+}
+
+func (a *Attendee) GetSentBy() string {
+	return a.sentBy
+}
+
+// sets the uri of the directory entry of the attendee
+func (a *Attendee) SetDir(dir string) *Attendee {
+	a.dir = dir
+	return a
+}
+
+func (a *Attendee) GetDir() string {
+	return a.dir
+}
+
+// sets the language of the name like en or de-CH
+func (a *Attendee) SetLanguage(language string) *Attendee {
+	a.language = language
+	return a
+}
+
+func (a *Attendee) GetLanguage() string {
+	return a.language
+}
+
 func (a *Attendee) String() string {
 
 	return fmt.Sprintf("%s with email %s", a.name, a.email)
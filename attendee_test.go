@@ -0,0 +1,86 @@
+package ics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAttendeeParameters(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:review\r\n"+
+		"DTSTART:20190603T100000Z\r\n"+
+		"ORGANIZER;CN=\"Doe; Jane\";SENT-BY=\"mailto:assistant@example.com\";DIR=\"ldap://example.com/cn=Jane\";LANGUAGE=en:mailto:jane@example.com\r\n"+
+		"ATTENDEE;CUTYPE=INDIVIDUAL;ROLE=chair;PARTSTAT=DELEGATED;RSVP=TRUE;DELEGATED-TO=\"mailto:bob@example.com\",\"mailto:sue@example.com\";CN=John:mailto:john@example.com\r\n"+
+		"ATTENDEE;ROLE=OPT-PARTICIPANT;DELEGATED-FROM=\"mailto:john@example.com\";MEMBER=\"mailto:team@example.com\";CN=Bob:mailto:bob@example.com\r\n"+
+		"ATTENDEE;CUTYPE=ROOM;CN=Room 1:mailto:room1@example.com\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+	event, err := cal.GetEventByImportedID("review")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+
+	check := func(e *Event) {
+		t.Helper()
+		organizer := e.GetOrganizer()
+		if organizer == nil || organizer.GetName() != "Doe; Jane" || organizer.GetEmail() != "jane@example.com" || organizer.GetSentBy() != "assistant@example.com" {
+			t.Fatalf("Unexpected organizer %v", organizer)
+		}
+		if organizer.GetDir() != "ldap://example.com/cn=Jane" || organizer.GetLanguage() != "en" {
+			t.Errorf("Unexpected organizer DIR %s or LANGUAGE %s", organizer.GetDir(), organizer.GetLanguage())
+		}
+
+		attendees := e.GetAttendees()
+		if len(attendees) != 3 {
+			t.Fatalf("Expected 3 attendees, got %d", len(attendees))
+		}
+		john, bob, room := attendees[0], attendees[1], attendees[2]
+		if john.GetName() != "John" || john.GetRole() != RoleChair || john.GetStatus() != PartStatDelegated || john.GetType() != CUTypeIndividual || !john.GetRSVP() {
+			t.Errorf("Unexpected attendee %s %s %s %s %t", john, john.GetRole(), john.GetStatus(), john.GetType(), john.GetRSVP())
+		}
+		if !reflect.DeepEqual(john.GetDelegatedTo(), []string{"bob@example.com", "sue@example.com"}) {
+			t.Errorf("Unexpected DELEGATED-TO %v", john.GetDelegatedTo())
+		}
+		if bob.GetRole() != RoleOptParticipant || bob.GetRSVP() || !reflect.DeepEqual(bob.GetDelegatedFrom(), []string{"john@example.com"}) || !reflect.DeepEqual(bob.GetMember(), []string{"team@example.com"}) {
+			t.Errorf("Unexpected attendee %s %s %v %v", bob, bob.GetRole(), bob.GetDelegatedFrom(), bob.GetMember())
+		}
+		if room.GetType() != CUTypeRoom || room.GetName() != "Room 1" {
+			t.Errorf("Unexpected attendee %s %s", room, room.GetType())
+		}
+	}
+	check(event)
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	rewritten, _ := loadSingleCalendar(t, written).GetEventByImportedID("review")
+	check(rewritten)
+}
+
+func TestWriteAttendeeAddresses(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:call\r\n"+
+		"DTSTART:20190603T100000Z\r\n"+
+		"ORGANIZER;SENT-BY=\"sip:assistant@example.com\":urn:uuid:3e5f4bd2-37c4-4b3c-9d5a-0e5cb2a9b7a1\r\n"+
+		"ATTENDEE;DELEGATED-TO=\"tel:+359-2-123-456\",\"mailto:sue@example.com\":MAILTO:john@example.com\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	// the addresses with other schemes than mailto: are written as they were read
+	unfolded := strings.ReplaceAll(string(written), "\r\n ", "")
+	for _, expected := range []string{
+		"ORGANIZER;SENT-BY=\"sip:assistant@example.com\":urn:uuid:3e5f4bd2-37c4-4b3c-9d5a-0e5cb2a9b7a1\r\n",
+		"ATTENDEE;DELEGATED-TO=\"tel:+359-2-123-456\",\"mailto:sue@example.com\":mailto:john@example.com\r\n",
+	} {
+		if !strings.Contains(unfolded, expected) {
+			t.Errorf("Expected %q in\n%s", expected, unfolded)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package ics
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotModified is returned by the fetchers when the calendar did not change since it was
+// fetched the last time. The parser then uses the calendars it parsed from the url before
+var ErrNotModified = errors.New("calendar not modified")
+
+// the context key that marks fetches that must return the content
+type unconditionalKey struct{}
+
+// reports if the fetch must return the content instead of ErrNotModified. The parser asks
+// for this when it has no calendars from the url to use , for example after a restart with disk cache
+func IsUnconditional(ctx context.Context) bool {
+	unconditional, _ := ctx.Value(unconditionalKey{}).(bool)
+	return unconditional
+}
+
+// CacheEntry has the validators of the last response for an url
+type CacheEntry struct {
+	etag         string
+	lastModified string
+}
+
+// creates new CacheEntry with the ETag and Last-Modified headers of a response
+func NewCacheEntry(etag, lastModified string) *CacheEntry {
+	return &CacheEntry{etag: etag, lastModified: lastModified}
+}
+
+func (e *CacheEntry) GetETag() string {
+	return e.etag
+}
+
+func (e *CacheEntry) GetLastModified() string {
+	return e.lastModified
+}
+
+// HTTPCache keeps the validators of the downloaded calendars by url
+type HTTPCache interface {
+	Get(url string) (*CacheEntry, bool)
+	Set(url string, entry *CacheEntry) error
+}
+
+// MemoryCache is HTTPCache that keeps the entries in memory
+type MemoryCache struct {
+	lock    *sync.RWMutex
+	entries map[string]*CacheEntry
+}
+
+// creates new empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{lock: new(sync.RWMutex), entries: make(map[string]*CacheEntry)}
+}
+
+func (c *MemoryCache) Get(url string) (*CacheEntry, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entry, ok := c.entries[url]
+	return entry, ok
+}
+
+func (c *MemoryCache) Set(url string, entry *CacheEntry) error {
+	c.lock.Lock()
+	c.entries[url] = entry
+	c.lock.Unlock()
+	return nil
+}
+
+// DiskCache is HTTPCache that keeps the entries as files in a folder , so they outlive the process
+type DiskCache struct {
+	dir string
+}
+
+// creates new DiskCache that keeps the entries in the folder , it is created when needed
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// the stored form of the entry
+type diskCacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+// returns the file of the entry for the url
+func (c *DiskCache) path(url string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(url))))
+}
+
+func (c *DiskCache) Get(url string) (*CacheEntry, bool) {
+	data, err := ioutil.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+	stored := diskCacheEntry{}
+	if err := json.Unmarshal(data, &stored); err != nil || stored.URL != url {
+		return nil, false
+	}
+	return NewCacheEntry(stored.ETag, stored.LastModified), true
+}
+
+func (c *DiskCache) Set(url string, entry *CacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0777); err != nil {
+		return err
+	}
+	data, err := json.Marshal(diskCacheEntry{URL: url, ETag: entry.GetETag(), LastModified: entry.GetLastModified()})
+	if err != nil {
+		return err
+	}
+	// write and rename , so a concurrent Get never reads half of the file
+	tmp, err := ioutil.TempFile(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(url))
+}
+
+// Stats has the counters of a parser
+type Stats struct {
+	cacheHits   int
+	cacheMisses int
+}
+
+// returns the number of urls that were not modified , their calendars were reused without parsing
+func (s Stats) GetCacheHits() int {
+	return s.cacheHits
+}
+
+// returns the number of urls that were fetched and parsed
+func (s Stats) GetCacheMisses() int {
+	return s.cacheMisses
+}
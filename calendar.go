@@ -4,22 +4,47 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 )
 
 type Calendar struct {
-	name              string
-	description       string
-	url               string
-	version           float64
-	timezone          time.Location
-	events            Events
-	eventsByDate      map[string][]*Event
-	eventByID         map[string]*Event
-	eventByImportedID map[string]*Event
+	name                string
+	description         string
+	url                 string
+	version             float64
+	prodID              string
+	method              string
+	calScale            string
+	refreshInterval     time.Duration
+	color               string
+	images              []*Image
+	source              string
+	timezone            time.Location
+	timezonesByID       map[string]*time.Location
+	timezoneDefs        map[string]*component
+	events              Events
+	eventsIndex         *eventIndex
+	eventByID           map[string]*Event
+	eventByImportedID   map[string]*Event
+	overridesByUID      map[string][]*Event
+	todos               []*Todo
+	todoByID            map[string]*Todo
+	todoByImportedID    map[string]*Todo
+	journals            []*Journal
+	journalsByDate      map[string][]*Journal
+	journalByID         map[string]*Journal
+	journalByImportedID map[string]*Journal
+	freeBusies          []*FreeBusy
+	errors              []*ParseError
+	propertySet
+	// the Windows time zone names known by the parser of the calendar
+	windowsZones map[string]string
+	// guards the events and their indexes , the todos and the journals
+	lock *sync.Mutex
 }
 
-type Events []Event
+type Events []*Event
 
 func (events Events) Len() int {
 	return len(events)
@@ -35,10 +60,26 @@ func (events Events) Swap(i, j int) {
 
 func NewCalendar() *Calendar {
 	c := new(Calendar)
+	c.lock = new(sync.Mutex)
+	c.windowsZones = defaultWindowsZones
 	// c.events = make([]Event)
-	c.eventsByDate = make(map[string][]*Event)
+	c.eventsIndex = newEventIndex()
 	c.eventByID = make(map[string]*Event)
 	c.eventByImportedID = make(map[string]*Event)
+	c.overridesByUID = make(map[string][]*Event)
+	c.timezonesByID = make(map[string]*time.Location)
+	c.timezoneDefs = make(map[string]*component)
+	c.errors = []*ParseError{}
+	c.todos = []*Todo{}
+	c.todoByID = make(map[string]*Todo)
+	c.todoByImportedID = make(map[string]*Todo)
+	c.journals = []*Journal{}
+	c.journalsByDate = make(map[string][]*Journal)
+	c.journalByID = make(map[string]*Journal)
+	c.journalByImportedID = make(map[string]*Journal)
+	c.freeBusies = []*FreeBusy{}
+	c.properties = []*Property{}
+	c.images = []*Image{}
 	return c
 }
 
@@ -69,6 +110,81 @@ func (c *Calendar) GetVersion() float64 {
 	return c.version
 }
 
+// sets the PRODID of the producer of the calendar
+func (c *Calendar) SetProdID(prodID string) *Calendar {
+	c.prodID = prodID
+	return c
+}
+
+func (c *Calendar) GetProdID() string {
+	return c.prodID
+}
+
+// sets the METHOD like PUBLISH for published calendars or REQUEST for invitations
+func (c *Calendar) SetMethod(method string) *Calendar {
+	c.method = method
+	return c
+}
+
+func (c *Calendar) GetMethod() string {
+	return c.method
+}
+
+func (c *Calendar) SetCalScale(calScale string) *Calendar {
+	c.calScale = calScale
+	return c
+}
+
+// returns the CALSCALE , empty when it is not set and GREGORIAN is meant
+func (c *Calendar) GetCalScale() string {
+	return c.calScale
+}
+
+// sets how often the calendar should be polled for updates
+func (c *Calendar) SetRefreshInterval(interval time.Duration) *Calendar {
+	c.refreshInterval = interval
+	return c
+}
+
+// returns the REFRESH-INTERVAL or the X-PUBLISHED-TTL , 0 when there is none
+func (c *Calendar) GetRefreshInterval() time.Duration {
+	return c.refreshInterval
+}
+
+// sets the CSS3 color name of the calendar
+func (c *Calendar) SetColor(color string) *Calendar {
+	c.color = color
+	return c
+}
+
+func (c *Calendar) GetColor() string {
+	return c.color
+}
+
+func (c *Calendar) SetImage(image *Image) *Calendar {
+	c.images = append(c.images, image)
+	return c
+}
+
+func (c *Calendar) SetImages(images []*Image) *Calendar {
+	c.images = images
+	return c
+}
+
+func (c *Calendar) GetImages() []*Image {
+	return c.images
+}
+
+// sets the uri where the calendar can be refreshed from
+func (c *Calendar) SetSource(source string) *Calendar {
+	c.source = source
+	return c
+}
+
+func (c *Calendar) GetSource() string {
+	return c.source
+}
+
 func (c *Calendar) SetTimezone(tz time.Location) *Calendar {
 	c.timezone = tz
 	return c
@@ -78,46 +194,100 @@ func (c *Calendar) GetTimezone() time.Location {
 	return c.timezone
 }
 
-//  add event to the calendar
-func (c *Calendar) SetEvent(event Event) (*Calendar, error) {
+// returns the time zone of the calendar as pointer that stays the same for the calendar
+func (c *Calendar) location() *time.Location {
+	return &c.timezone
+}
+
+// sets the time zone used for the TZID , usually from the VTIMEZONE definition in the calendar
+func (c *Calendar) SetTimezoneByID(tzID string, loc *time.Location) *Calendar {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.timezonesByID[tzID] = loc
+	return c
+}
+
+// returns the time zone for the TZID. The VTIMEZONE definitions in the calendar are used first ,
+// then the IANA time zone database and then the Windows names known by the parser of the calendar
+func (c *Calendar) GetTimezoneByID(tzID string) (*time.Location, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if loc, ok := c.timezonesByID[tzID]; ok {
+		return loc, nil
+	}
+	loc, err := loadLocation(tzID, c.windowsZones)
+	if err != nil {
+		return nil, err
+	}
+	c.timezonesByID[tzID] = loc
+	return loc, nil
+}
+
+// returns the TZIDs with known time zone in the calendar
+func (c *Calendar) GetTimezoneIDs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ids := []string{}
+	for id := range c.timezonesByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// keeps the VTIMEZONE definition of the TZID , so that the calendar is written back with it
+func (c *Calendar) setTimezoneDef(tzID string, def *component) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.timezoneDefs[tzID] = def
+}
+
+// returns the VTIMEZONE definition of the TZID read with the calendar , nil when there is none
+func (c *Calendar) getTimezoneDef(tzID string) *component {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.timezoneDefs[tzID]
+}
+
+// adds the event to the calendar , the calendar keeps the pointer so the changes of the event
+// are seen by all the getters
+func (c *Calendar) SetEvent(event *Event) (*Calendar, error) {
 	//  lock so that the events array doesn't change its size from other goruote
-	mutex.Lock()
+	c.lock.Lock()
 
 	// reference to the calendar
 	if event.GetCalendar() == nil || event.GetCalendar() != c {
 		event.SetCalendar(c)
 	}
-	// add the event to the main array with events
+	// add the event to the main array with events ,
+	// the indexes point to the same event
 	c.events = append(c.events, event)
 
-	// pointer to the added event in the main array
-	eventPtr := &c.events[len(c.events)-1]
-
-	// calculate the start and end day of the event
-	eventStartTime := event.GetStart()
-	eventEndTime := event.GetEnd()
-	tz := c.GetTimezone()
-	eventStartDate := time.Date(eventStartTime.Year(), eventStartTime.Month(), eventStartTime.Day(), 0, 0, 0, 0, &tz)
-	eventEndDate := time.Date(eventEndTime.Year(), eventEndTime.Month(), eventEndTime.Day(), 0, 0, 0, 0, &tz)
-
-	// faster search by date, add each date from start to end date
-	for eventDate := eventStartDate; eventDate.Before(eventEndDate) || eventDate.Equal(eventEndDate); eventDate = eventDate.Add(24 * time.Hour) {
-		c.eventsByDate[eventDate.Format(YmdHis)] = append(c.eventsByDate[eventDate.Format(YmdHis)], eventPtr)
-	}
+	// faster search by time
+	c.eventsIndex.add(event)
 
 	// faster search by id
-	c.eventByID[event.GetID()] = eventPtr
+	c.eventByID[event.GetID()] = event
 
 	if event.GetImportedID() != "" {
-		c.eventByImportedID[event.GetImportedID()] = eventPtr
+		// the events that replace an instance of a repeating event do not hide the repeating one
+		_, found := c.eventByImportedID[event.GetImportedID()]
+		if event.GetRecurrenceID().IsZero() || !found {
+			c.eventByImportedID[event.GetImportedID()] = event
+		}
+		if !event.GetRecurrenceID().IsZero() {
+			c.overridesByUID[event.GetImportedID()] = append(c.overridesByUID[event.GetImportedID()], event)
+		}
 	}
 
-	mutex.Unlock()
+	c.lock.Unlock()
 	return c, nil
 }
 
 //  get event by id
 func (c *Calendar) GetEventByID(eventID string) (*Event, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	event, ok := c.eventByID[eventID]
 	if ok {
 		return event, nil
@@ -127,6 +297,8 @@ func (c *Calendar) GetEventByID(eventID string) (*Event, error) {
 
 //  get event by imported id
 func (c *Calendar) GetEventByImportedID(eventID string) (*Event, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	event, ok := c.eventByImportedID[eventID]
 	if ok {
 		return event, nil
@@ -134,47 +306,236 @@ func (c *Calendar) GetEventByImportedID(eventID string) (*Event, error) {
 	return nil, errors.New(fmt.Sprintf("There is no event with id %s", eventID))
 }
 
-//  get all events in the calendar
-func (c *Calendar) GetEvents() []Event {
-	return c.events
+// returns the event that replaces the instance of the repeating event with the given UID and start
+func (c *Calendar) getOverride(uid string, recurrenceID time.Time) *Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, override := range c.overridesByUID[uid] {
+		if override.GetRecurrenceID().Equal(recurrenceID) {
+			return override
+		}
+	}
+	return nil
+}
+
+// returns all the events in the calendar in the order they were added
+func (c *Calendar) GetEvents() []*Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*Event{}, c.events...)
 }
 
-//  get all events in the calendar ordered by date
+// returns the events in the calendar by the days they take place ,
+// the keys are the days in the timezone of the calendar in YmdHis format
 func (c *Calendar) GetEventsByDates() map[string][]*Event {
-	return c.eventsByDate
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	tz := c.GetTimezone()
+	eventsByDate := make(map[string][]*Event)
+	for _, event := range c.events {
+		start, end := event.GetStart().In(&tz), event.effectiveEnd()
+		first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, &tz)
+		for day := first; day.Equal(first) || day.Before(end); day = day.AddDate(0, 0, 1) {
+			eventsByDate[day.Format(YmdHis)] = append(eventsByDate[day.Format(YmdHis)], event)
+		}
+	}
+	return eventsByDate
 }
 
 // get all events for specified date
 func (c *Calendar) GetEventsByDate(dateTime time.Time) ([]*Event, error) {
 	tz := c.GetTimezone()
 	day := time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), 0, 0, 0, 0, &tz)
-	events, ok := c.eventsByDate[day.Format(YmdHis)]
-	if ok {
+	events := c.GetEventsBetween(day, day.AddDate(0, 0, 1))
+	if len(events) > 0 {
 		return events, nil
 	}
 	return nil, errors.New(fmt.Sprintf("There are no events for the day %s", day.Format(YmdHis)))
 }
 
-// GetUpcomingEvents returns the next n-Events.
-func (c *Calendar) GetUpcomingEvents(n int) []Event {
-	upcomingEvents := []Event{}
+// returns the events that overlap the time between from and to ordered by start ,
+// the events without duration are returned when they start in it
+func (c *Calendar) GetEventsBetween(from, to time.Time) []*Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.eventsIndex.overlapping(from, to)
+}
 
-	// sort events of calendar
-	sort.Sort(c.events)
+// returns the events that take place at the time
+func (c *Calendar) GetEventsAt(t time.Time) []*Event {
+	// the events that start at the time are in the nanosecond after it
+	return c.GetEventsBetween(t, t.Add(time.Nanosecond))
+}
 
-	now := time.Now()
-	// find next event
-	for _, event := range c.events {
-		if event.GetStart().After(now) {
-			upcomingEvents = append(upcomingEvents, event)
-			// break if we collect enough events
-			if len(upcomingEvents) >= n {
-				break
-			}
-		}
+// returns the first event that starts after the time , nil when there is none
+func (c *Calendar) GetNextEvent(t time.Time) *Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.eventsIndex.next(t)
+}
+
+// puts the event at its new time in the index , the events of other calendars are ignored
+func (c *Calendar) moveEvent(event *Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.eventsIndex.move(event)
+}
+
+// add todo to the calendar
+func (c *Calendar) SetTodo(todo *Todo) *Calendar {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	todo.SetCalendar(c)
+	c.todos = append(c.todos, todo)
+	c.todoByID[todo.GetID()] = todo
+	if todo.GetImportedID() != "" {
+		c.todoByImportedID[todo.GetImportedID()] = todo
+	}
+	return c
+}
+
+// get all todos in the calendar
+func (c *Calendar) GetTodos() []*Todo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*Todo{}, c.todos...)
+}
+
+// get todo by id
+func (c *Calendar) GetTodoByID(todoID string) (*Todo, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	todo, ok := c.todoByID[todoID]
+	if ok {
+		return todo, nil
 	}
+	return nil, fmt.Errorf("There is no todo with id %s", todoID)
+}
 
-	return upcomingEvents
+// get todo by imported id
+func (c *Calendar) GetTodoByImportedID(todoID string) (*Todo, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	todo, ok := c.todoByImportedID[todoID]
+	if ok {
+		return todo, nil
+	}
+	return nil, fmt.Errorf("There is no todo with id %s", todoID)
+}
+
+// add journal to the calendar
+func (c *Calendar) SetJournal(journal *Journal) *Calendar {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	journal.SetCalendar(c)
+	c.journals = append(c.journals, journal)
+
+	// faster search by date
+	if !journal.GetStart().IsZero() {
+		start := journal.GetStart()
+		tz := c.GetTimezone()
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, &tz).Format(YmdHis)
+		c.journalsByDate[date] = append(c.journalsByDate[date], journal)
+	}
+
+	c.journalByID[journal.GetID()] = journal
+	if journal.GetImportedID() != "" {
+		c.journalByImportedID[journal.GetImportedID()] = journal
+	}
+	return c
+}
+
+// get all journals in the calendar
+func (c *Calendar) GetJournals() []*Journal {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*Journal{}, c.journals...)
+}
+
+// get journal by id
+func (c *Calendar) GetJournalByID(journalID string) (*Journal, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	journal, ok := c.journalByID[journalID]
+	if ok {
+		return journal, nil
+	}
+	return nil, fmt.Errorf("There is no journal with id %s", journalID)
+}
+
+// get journal by imported id
+func (c *Calendar) GetJournalByImportedID(journalID string) (*Journal, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	journal, ok := c.journalByImportedID[journalID]
+	if ok {
+		return journal, nil
+	}
+	return nil, fmt.Errorf("There is no journal with id %s", journalID)
+}
+
+// get all journals in the calendar ordered by date
+func (c *Calendar) GetJournalsByDates() map[string][]*Journal {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	journalsByDate := make(map[string][]*Journal, len(c.journalsByDate))
+	for date, journals := range c.journalsByDate {
+		journalsByDate[date] = append([]*Journal{}, journals...)
+	}
+	return journalsByDate
+}
+
+// get all journals for specified date
+func (c *Calendar) GetJournalsByDate(dateTime time.Time) ([]*Journal, error) {
+	tz := c.GetTimezone()
+	day := time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), 0, 0, 0, 0, &tz)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	journals, ok := c.journalsByDate[day.Format(YmdHis)]
+	if ok {
+		return append([]*Journal{}, journals...), nil
+	}
+	return nil, fmt.Errorf("There are no journals for the day %s", day.Format(YmdHis))
+}
+
+// adds a VFREEBUSY to the calendar
+func (c *Calendar) SetFreeBusy(fb *FreeBusy) *Calendar {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if fb.GetCalendar() == nil {
+		fb.SetCalendar(c)
+	}
+	c.freeBusies = append(c.freeBusies, fb)
+	return c
+}
+
+// returns the VFREEBUSYs in the calendar
+func (c *Calendar) GetFreeBusies() []*FreeBusy {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*FreeBusy{}, c.freeBusies...)
+}
+
+// GetUpcomingEvents returns the next n-Events.
+func (c *Calendar) GetUpcomingEvents(n int) []*Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// the index keeps the events ordered by start , the events of the calendar are not reordered
+	return c.eventsIndex.startingAfter(time.Now(), n)
+}
+
+// adds a raw property , the unknown and X- properties are written back as they are
+func (c *Calendar) SetProperty(p *Property) *Calendar {
+	c.appendProperty(p)
+	return c
+}
+
+// replaces all the raw properties
+func (c *Calendar) SetProperties(properties []*Property) *Calendar {
+	c.replaceProperties(properties)
+	return c
 }
 
 func (c *Calendar) String() string {
@@ -185,6 +546,19 @@ func (c *Calendar) String() string {
 	return fmt.Sprintf("Calendar %s about %s has %d events. Downloaded from %s .", name, desc, eventsCount, url)
 }
 
+// returns the errors occurred while parsing the content of the calendar
+func (c *Calendar) GetErrors() []*ParseError {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*ParseError{}, c.errors...)
+}
+
+func (c *Calendar) addError(err *ParseError) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.errors = append(c.errors, err)
+}
+
 func (c *Calendar) SetUrl(u string) *Calendar {
 	c.url = u
 	return c
@@ -0,0 +1,127 @@
+package ics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCalendarKeepsEventPointers(t *testing.T) {
+	cal := NewCalendar()
+	start := time.Now().Add(time.Hour)
+	added := []*Event{}
+	// enough events for the storage to grow many times
+	for i := 0; i < 100; i++ {
+		event := NewEvent()
+		event.SetImportedID(fmt.Sprintf("event-%d", i))
+		event.SetStart(start.Add(time.Duration(100-i) * time.Hour))
+		event.SetEnd(event.GetStart().Add(time.Hour))
+		event.SetID(event.GenerateEventId())
+		cal.SetEvent(event)
+		added = append(added, event)
+	}
+
+	upcoming := cal.GetUpcomingEvents(3)
+	if len(upcoming) != 3 || upcoming[0] != added[99] || upcoming[2] != added[97] {
+		t.Errorf("Expected the last added events as upcoming , found %v", upcoming)
+	}
+
+	for i, event := range cal.GetEvents() {
+		if event != added[i] {
+			t.Fatalf("Expected the events in the order they were added , event %d differs", i)
+		}
+	}
+
+	byID, err := cal.GetEventByID(added[0].GetID())
+	if err != nil || byID != added[0] {
+		t.Fatalf("Expected the added event by id , found %v with error %v", byID, err)
+	}
+	byID.SetSummary("Moved")
+	byImportedID, _ := cal.GetEventByImportedID("event-0")
+	byTime := cal.GetEventsAt(added[0].GetStart())
+	if cal.GetEvents()[0].GetSummary() != "Moved" || byImportedID.GetSummary() != "Moved" || len(byTime) != 1 || byTime[0] != byID {
+		t.Errorf("Expected the change of the event in all the getters")
+	}
+}
+
+func TestCalendarMovesEvents(t *testing.T) {
+	cal := NewCalendar()
+	start := time.Date(2019, time.June, 4, 14, 0, 0, 0, time.UTC)
+	event := NewEvent().SetStart(start).SetEnd(start.Add(time.Hour))
+	event.SetImportedID("call")
+	event.SetID(event.GenerateEventId())
+	cal.SetEvent(event)
+
+	moved, _ := cal.GetEventByID(event.GetID())
+	moved.SetStart(start.AddDate(0, 0, 1)).SetEnd(start.AddDate(0, 0, 1).Add(time.Hour))
+
+	if old := cal.GetEventsAt(start); len(old) != 0 {
+		t.Errorf("Expected no event at the old time , found %v", old)
+	}
+	if _, err := cal.GetEventsByDate(start); err == nil {
+		t.Errorf("Expected no event at the old day")
+	}
+	if now := cal.GetEventsAt(start.AddDate(0, 0, 1)); len(now) != 1 || now[0] != event {
+		t.Errorf("Expected the event at the new time , found %v", now)
+	}
+	if byDate, err := cal.GetEventsByDate(start.AddDate(0, 0, 1)); err != nil || len(byDate) != 1 {
+		t.Errorf("Expected the event at the new day , found %v with error %v", byDate, err)
+	}
+	if next := cal.GetNextEvent(start); next != event {
+		t.Errorf("Expected the moved event next , found %v", next)
+	}
+
+	// the whole day events last until the end of the day
+	moved.SetEnd(time.Time{}).SetWholeDayEvent(true)
+	if late := cal.GetEventsAt(start.AddDate(0, 0, 1).Add(8 * time.Hour)); len(late) != 1 {
+		t.Errorf("Expected the whole day event late in the day , found %v", late)
+	}
+}
+
+func TestCalendarConcurrentAccess(t *testing.T) {
+	cal := NewCalendar()
+	start := time.Date(2019, time.June, 4, 14, 0, 0, 0, time.UTC)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			todo := NewTodo()
+			todo.SetImportedID(fmt.Sprintf("todo-%d", i))
+			todo.SetID(todo.GenerateTodoId())
+			cal.SetTodo(todo)
+			journal := NewJournal()
+			journal.SetImportedID(fmt.Sprintf("journal-%d", i))
+			journal.SetStart(start)
+			journal.SetID(journal.GenerateJournalId())
+			cal.SetJournal(journal)
+			cal.SetFreeBusy(NewFreeBusy().SetStart(start).SetEnd(start.Add(time.Hour)))
+			cal.addError(newParseError("", i, "VTODO", "DUE", fmt.Errorf("invalid due")))
+			cal.SetTimezoneByID(fmt.Sprintf("Zone %d", i), time.UTC)
+			cal.GetTimezoneByID("UTC")
+		}
+	}()
+	// the getters and the writer run while the calendar is filled , the race detector checks them
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		cal.GetTodos()
+		cal.GetTodoByImportedID("todo-1")
+		cal.GetJournals()
+		cal.GetJournalByImportedID("journal-1")
+		cal.GetJournalsByDates()
+		cal.GetJournalsByDate(start)
+		cal.GetFreeBusies()
+		cal.GetErrors()
+		cal.GetTimezoneByID("Europe/Sofia")
+		cal.GetTimezoneIDs()
+		if _, err := cal.MarshalICS(); err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+	}
+	if len(cal.GetTodos()) != 50 || len(cal.GetJournals()) != 50 || len(cal.GetFreeBusies()) != 50 || len(cal.GetErrors()) != 50 {
+		t.Errorf("Expected 50 todos , journals , free/busy and errors")
+	}
+}
@@ -0,0 +1,133 @@
+package ics
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Conference is a RFC 7986 CONFERENCE property , a link to join a meeting
+type Conference struct {
+	uri      string
+	features []string
+	label    string
+	detected bool
+}
+
+// creates new conference with the uri to join it
+func NewConference(uri string) *Conference {
+	c := new(Conference)
+	c.uri = uri
+	c.features = []string{}
+	return c
+}
+
+func (c *Conference) GetURI() string {
+	return c.uri
+}
+
+// adds a feature like AUDIO , CHAT , FEED , MODERATOR , PHONE , SCREEN or VIDEO
+func (c *Conference) SetFeature(feature string) *Conference {
+	c.features = append(c.features, feature)
+	return c
+}
+
+func (c *Conference) SetFeatures(features []string) *Conference {
+	c.features = features
+	return c
+}
+
+func (c *Conference) GetFeatures() []string {
+	return c.features
+}
+
+// reports if the conference has the feature
+func (c *Conference) HasFeature(feature string) bool {
+	for _, f := range c.features {
+		if strings.EqualFold(f, feature) {
+			return true
+		}
+	}
+	return false
+}
+
+// sets the text to show for the link
+func (c *Conference) SetLabel(label string) *Conference {
+	c.label = label
+	return c
+}
+
+func (c *Conference) GetLabel() string {
+	return c.label
+}
+
+// reports if the link was found in the LOCATION or the DESCRIPTION of the event
+// and not in a CONFERENCE property. Such links are not written back
+func (c *Conference) IsDetected() bool {
+	return c.detected
+}
+
+func (c *Conference) String() string {
+	if c.label != "" {
+		return c.label + " " + c.uri
+	}
+	return c.uri
+}
+
+// the links in free text
+var linkRegexp = regexp.MustCompile(`https?://[^\s<>"'\\]+`)
+
+// the hosts of the well known meeting services and their names , the subdomains match too
+var meetingHosts = []struct {
+	host  string
+	label string
+}{
+	{"zoom.us", "Zoom"},
+	{"meet.google.com", "Google Meet"},
+	{"teams.microsoft.com", "Microsoft Teams"},
+	{"teams.live.com", "Microsoft Teams"},
+	{"webex.com", "Webex"},
+	{"gotomeeting.com", "GoToMeeting"},
+	{"gotomeet.me", "GoToMeeting"},
+	{"meet.jit.si", "Jitsi Meet"},
+	{"whereby.com", "Whereby"},
+	{"bluejeans.com", "BlueJeans"},
+	{"chime.aws", "Amazon Chime"},
+}
+
+// finds the links to well known meeting services in the texts
+func detectConferences(texts ...string) []*Conference {
+	conferences := []*Conference{}
+	found := []string{}
+	for _, text := range texts {
+		for _, link := range linkRegexp.FindAllString(text, -1) {
+			// punctuation after the link is part of the text
+			link = strings.TrimRight(link, ".,;:!?)]}>")
+			label := meetingLabel(link)
+			if label == "" || containsString(found, link) {
+				continue
+			}
+			found = append(found, link)
+
+			conference := NewConference(link).SetFeature("VIDEO").SetLabel(label)
+			conference.detected = true
+			conferences = append(conferences, conference)
+		}
+	}
+	return conferences
+}
+
+// returns the name of the meeting service of the link , empty when it is not a meeting link
+func meetingLabel(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, meeting := range meetingHosts {
+		if host == meeting.host || strings.HasSuffix(host, "."+meeting.host) {
+			return meeting.label
+		}
+	}
+	return ""
+}
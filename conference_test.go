@@ -0,0 +1,73 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+)
+
+const meetingsCal = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:sync\r\n" +
+	"DTSTART:20190603T100000Z\r\n" +
+	"COLOR:dark-red\r\n" +
+	"IMAGE;VALUE=URI;DISPLAY=BADGE;FMTTYPE=image/png:https://example.com/sync.png\r\n" +
+	"IMAGE;ENCODING=BASE64;VALUE=BINARY;DISPLAY=THUMBNAIL:aGVsbG8=\r\n" +
+	"CONFERENCE;VALUE=URI;FEATURE=AUDIO,VIDEO;LABEL=Join:https://chat.example.com/sync\r\n" +
+	"CONFERENCE;VALUE=URI;FEATURE=PHONE;LABEL=Dial in:tel:+1-555-0100\r\n" +
+	"DESCRIPTION:Also on https://zoom.us/j/123\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"DTSTART:20190603T110000Z\r\n" +
+	"LOCATION:https://meet.google.com/abc-defg-hij\r\n" +
+	"DESCRIPTION:Join (https://acme.zoom.us/j/123?pwd=x). Docs at https://example.com/docs\\, or https://meet.google.com/abc-defg-hij\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestEventConferences(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(meetingsCal))
+
+	sync, _ := cal.GetEventByImportedID("sync")
+	if sync.GetColor() != "dark-red" {
+		t.Errorf("Unexpected color %s", sync.GetColor())
+	}
+	images := sync.GetImages()
+	if len(images) != 2 || images[0].GetDisplay() != "BADGE" || !images[1].IsBinary() {
+		t.Fatalf("Unexpected images %v", images)
+	}
+	if data, err := images[1].GetData(); err != nil || string(data) != "hello" {
+		t.Errorf("Expected inline image hello, got %q ( %v )", data, err)
+	}
+	conferences := sync.GetConferences()
+	if len(conferences) != 2 {
+		t.Fatalf("Expected only the CONFERENCE links, got %v", conferences)
+	}
+	if !conferences[0].HasFeature("video") || conferences[0].GetLabel() != "Join" || conferences[1].GetURI() != "tel:+1-555-0100" || conferences[0].IsDetected() {
+		t.Errorf("Unexpected conferences %v", conferences)
+	}
+
+	standup, _ := cal.GetEventByImportedID("standup")
+	conferences = standup.GetConferences()
+	if len(conferences) != 2 {
+		t.Fatalf("Expected 2 meeting links, got %v", conferences)
+	}
+	if conferences[0].GetURI() != "https://meet.google.com/abc-defg-hij" || conferences[0].GetLabel() != "Google Meet" || !conferences[0].IsDetected() {
+		t.Errorf("Unexpected conference %v", conferences[0])
+	}
+	if conferences[1].GetURI() != "https://acme.zoom.us/j/123?pwd=x" || conferences[1].GetLabel() != "Zoom" {
+		t.Errorf("Unexpected conference %v", conferences[1])
+	}
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if strings.Count(string(written), "CONFERENCE") != 2 {
+		t.Errorf("Expected only the parsed conferences in\n%s", written)
+	}
+	parsed := loadSingleCalendar(t, written)
+	rewritten, _ := parsed.GetEventByImportedID("sync")
+	if rewritten.GetColor() != "dark-red" || len(rewritten.GetImages()) != 2 || len(rewritten.GetConferences()) != 2 || rewritten.GetConferences()[1].GetLabel() != "Dial in" {
+		t.Errorf("Expected the properties to survive writing in\n%s", written)
+	}
+}
@@ -0,0 +1,65 @@
+package ics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is an error that occurred while getting or parsing a calendar. It tells
+// the source of the calendar and , when known , the line , the component and the property
+type ParseError struct {
+	url       string
+	line      int
+	component string
+	property  string
+	err       error
+}
+
+func newParseError(url string, line int, component, property string, err error) *ParseError {
+	return &ParseError{url: url, line: line, component: component, property: property, err: err}
+}
+
+// returns the url or the path of the calendar , empty for the calendars loaded with Load or LoadReader
+func (e *ParseError) GetURL() string {
+	return e.url
+}
+
+// returns the line of the content where the error is , 0 when it is not about a single line
+func (e *ParseError) GetLine() int {
+	return e.line
+}
+
+// returns the name of the component like VEVENT
+func (e *ParseError) GetComponent() string {
+	return e.component
+}
+
+// returns the name of the property like DTSTART
+func (e *ParseError) GetProperty() string {
+	return e.property
+}
+
+func (e *ParseError) Error() string {
+	where := []string{}
+	if e.url != "" {
+		where = append(where, e.url)
+	}
+	if e.line > 0 {
+		where = append(where, fmt.Sprintf("line %d", e.line))
+	}
+	if e.component != "" {
+		where = append(where, e.component)
+	}
+	if e.property != "" {
+		where = append(where, e.property)
+	}
+	if len(where) == 0 {
+		return e.err.Error()
+	}
+	return strings.Join(where, " ") + ": " + e.err.Error()
+}
+
+// returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
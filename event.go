@@ -3,6 +3,8 @@ package ics
 import (
 	"crypto/md5"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -21,24 +23,64 @@ type Event struct {
 	geo           *Geo
 	summary       string
 	rrule         string
+	exDates       []time.Time
+	rDates        []time.Time
+	recurrenceID  time.Time
 	class         string
+	transp        string
 	id            string
 	sequence      int
 	attendees     []*Attendee
 	organizer     *Attendee
+	alarms        []*Alarm
+	color         string
+	images        []*Image
+	conferences   []*Conference
+	url           string
+	priority      int
+	categories    []string
+	comments      []string
+	contacts      []string
+	resources     []string
+	attachments   []*Attachment
+	relatedTo     []string
+	stamp         time.Time
+	requestStatus []*RequestStatus
+	summaries     []*Text
+	descriptions  []*Text
+	locations     []*Text
 	wholeDayEvent bool
+	generated     bool
 	inCalendar    *Calendar
 	alarmCallback func(*Event)
+	propertySet
 }
 
 func NewEvent() *Event {
 	e := new(Event)
 	e.attendees = []*Attendee{}
+	e.alarms = []*Alarm{}
+	e.images = []*Image{}
+	e.conferences = []*Conference{}
+	e.categories = []string{}
+	e.comments = []string{}
+	e.contacts = []string{}
+	e.resources = []string{}
+	e.attachments = []*Attachment{}
+	e.relatedTo = []string{}
+	e.requestStatus = []*RequestStatus{}
+	e.summaries = []*Text{}
+	e.descriptions = []*Text{}
+	e.locations = []*Text{}
+	e.properties = []*Property{}
+	e.exDates = []time.Time{}
+	e.rDates = []time.Time{}
 	return e
 }
 
 func (e *Event) SetStart(start time.Time) *Event {
 	e.start = start
+	e.reindex()
 	return e
 }
 
@@ -48,6 +90,7 @@ func (e *Event) GetStart() time.Time {
 
 func (e *Event) SetEnd(end time.Time) *Event {
 	e.end = end
+	e.reindex()
 	return e
 }
 
@@ -118,6 +161,16 @@ func (e *Event) GetClass() string {
 	return e.class
 }
 
+// sets if the event blocks time , OPAQUE or TRANSPARENT
+func (e *Event) SetTransp(transp string) *Event {
+	e.transp = transp
+	return e
+}
+
+func (e *Event) GetTransp() string {
+	return e.transp
+}
+
 func (e *Event) SetCreated(created time.Time) *Event {
 	e.created = created
 	return e
@@ -163,6 +216,25 @@ func (e *Event) GetSummary() string {
 	return e.summary
 }
 
+// sets the SUMMARY in all the languages , the first one is the default
+func (e *Event) SetSummaryTexts(summaries []*Text) *Event {
+	e.summaries = summaries
+	return e
+}
+
+// returns the SUMMARY in all the languages with their ALTREP
+func (e *Event) GetSummaryTexts() []*Text {
+	return e.summaries
+}
+
+// returns the summary in the language , or the default one when there is none in that language
+func (e *Event) GetSummaryFor(language string) string {
+	if t := textFor(e.summaries, language); t != nil {
+		return t.GetValue()
+	}
+	return e.summary
+}
+
 func (e *Event) SetDescription(description string) *Event {
 	e.description = description
 	return e
@@ -172,6 +244,25 @@ func (e *Event) GetDescription() string {
 	return e.description
 }
 
+// sets the DESCRIPTION in all the languages , the first one is the default
+func (e *Event) SetDescriptionTexts(descriptions []*Text) *Event {
+	e.descriptions = descriptions
+	return e
+}
+
+// returns the DESCRIPTION in all the languages with their ALTREP
+func (e *Event) GetDescriptionTexts() []*Text {
+	return e.descriptions
+}
+
+// returns the description in the language , or the default one when there is none in that language
+func (e *Event) GetDescriptionFor(language string) string {
+	if t := textFor(e.descriptions, language); t != nil {
+		return t.GetValue()
+	}
+	return e.description
+}
+
 func (e *Event) SetRRule(rrule string) *Event {
 	e.rrule = rrule
 	return e
@@ -181,11 +272,105 @@ func (e *Event) GetRRule() string {
 	return e.rrule
 }
 
+// adds a date excluded from the instances of the event (EXDATE)
+func (e *Event) SetExDate(exDate time.Time) *Event {
+	e.exDates = append(e.exDates, exDate)
+	return e
+}
+
+func (e *Event) SetExDates(exDates []time.Time) *Event {
+	e.exDates = append(e.exDates, exDates...)
+	return e
+}
+
+func (e *Event) GetExDates() []time.Time {
+	return e.exDates
+}
+
+// adds a date to the instances of the event (RDATE)
+func (e *Event) SetRDate(rDate time.Time) *Event {
+	e.rDates = append(e.rDates, rDate)
+	return e
+}
+
+func (e *Event) SetRDates(rDates []time.Time) *Event {
+	e.rDates = append(e.rDates, rDates...)
+	return e
+}
+
+func (e *Event) GetRDates() []time.Time {
+	return e.rDates
+}
+
+// sets the start of the instance of the repeating event with the same UID that this event replaces
+func (e *Event) SetRecurrenceID(recurrenceID time.Time) *Event {
+	e.recurrenceID = recurrenceID
+	return e
+}
+
+// returns the RECURRENCE-ID , zero time when the event does not replace an instance
+func (e *Event) GetRecurrenceID() time.Time {
+	return e.recurrenceID
+}
+
+// returns the parsed RRULE of the event , nil when the event does not repeat
+func (e *Event) GetRecurrenceRule() (*RecurrenceRule, error) {
+	if e.rrule == "" {
+		return nil, nil
+	}
+	return ParseRecurrenceRule(e.rrule)
+}
+
+// Occurrences returns iterator over the instances of the event in RRULE and RDATE without
+// the ones in EXDATE. Instances replaced by another event with the same UID and matching
+// RECURRENCE-ID in the calendar are returned as that event. An event without RRULE or RDATE
+// has a single instance. Rules without COUNT or UNTIL never end , so stop iterating when
+// you are past the dates you are interested in
+func (e *Event) Occurrences() *OccurrenceIterator {
+	it := &OccurrenceIterator{event: e, duration: e.GetEnd().Sub(e.GetStart())}
+	if rule, err := e.GetRecurrenceRule(); err == nil && rule != nil {
+		it.rule = rule.Iterator(e.GetStart())
+	}
+	it.rDates = append([]time.Time{}, e.GetRDates()...)
+	sort.Slice(it.rDates, func(i, j int) bool { return it.rDates[i].Before(it.rDates[j]) })
+	return it
+}
+
+// checks if the instance starting at the given time is excluded by EXDATE
+func (e *Event) isExcluded(start time.Time) bool {
+	for _, exDate := range e.exDates {
+		if exDate.Equal(start) {
+			return true
+		}
+		// whole day instances are matched by their date
+		if e.IsWholeDay() {
+			ey, em, ed := exDate.Date()
+			sy, sm, sd := start.Date()
+			if ey == sy && em == sm && ed == sd {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// creates a copy of the event for one of its instances
+func (e *Event) newOccurrence(start, end time.Time) *Event {
+	newE := e.Clone()
+	newE.SetStart(start)
+	newE.SetEnd(end)
+	newE.SetID(newE.GenerateEventId())
+	newE.generated = true
+	return newE
+}
+
 func (e *Event) Clone() *Event {
 	newE := *e
 	return &newE
 }
 
+// Deprecated: the timer is not stopped with the parser , use the Scheduler that
+// fires the VALARMs of the events
 func (e *Event) SetAlarm(alarmAfter time.Duration, callback func(*Event)) *Event {
 	e.alarmCallback = callback
 	e.alarmTime = alarmAfter
@@ -206,8 +391,25 @@ func (e *Event) GetAlarmTime() time.Duration {
 	return e.alarmTime
 }
 
+// adds a VALARM to the event
+func (e *Event) AddAlarm(a *Alarm) *Event {
+	e.alarms = append(e.alarms, a)
+	return e
+}
+
+func (e *Event) SetAlarms(alarms []*Alarm) *Event {
+	e.alarms = alarms
+	return e
+}
+
+// returns the VALARMs of the event
+func (e *Event) GetAlarms() []*Alarm {
+	return e.alarms
+}
+
 func (e *Event) SetWholeDayEvent(wholeDay bool) *Event {
 	e.wholeDayEvent = wholeDay
+	e.reindex()
 	return e
 }
 
@@ -219,6 +421,18 @@ func (e *Event) IsWholeDay() bool {
 	return e.wholeDayEvent
 }
 
+// returns the end of the event , the whole day events without end last the whole day
+func (e *Event) effectiveEnd() time.Time {
+	end := e.GetEnd()
+	if e.IsWholeDay() && !end.After(e.GetStart()) {
+		end = e.GetStart().AddDate(0, 0, 1)
+	}
+	if end.Before(e.GetStart()) {
+		end = e.GetStart()
+	}
+	return end
+}
+
 //  generates an unique id for the event
 func (e *Event) GenerateEventId() string {
 	if e.GetImportedID() != "" {
@@ -240,6 +454,13 @@ func (e *Event) GetCalendar() *Calendar {
 	return e.inCalendar
 }
 
+// moves the event in the time index of its calendar after its start or end has changed
+func (e *Event) reindex() {
+	if e.inCalendar != nil {
+		e.inCalendar.moveEvent(e)
+	}
+}
+
 func (e *Event) SetLocation(location string) *Event {
 	e.location = location
 	return e
@@ -249,6 +470,207 @@ func (e *Event) GetLocation() string {
 	return e.location
 }
 
+// sets the CSS3 color name of the event
+func (e *Event) SetColor(color string) *Event {
+	e.color = color
+	return e
+}
+
+func (e *Event) GetColor() string {
+	return e.color
+}
+
+func (e *Event) SetImage(image *Image) *Event {
+	e.images = append(e.images, image)
+	return e
+}
+
+func (e *Event) SetImages(images []*Image) *Event {
+	e.images = images
+	return e
+}
+
+func (e *Event) GetImages() []*Image {
+	return e.images
+}
+
+// adds a link to join the meeting
+func (e *Event) SetConference(conference *Conference) *Event {
+	e.conferences = append(e.conferences, conference)
+	return e
+}
+
+func (e *Event) SetConferences(conferences []*Conference) *Event {
+	e.conferences = conferences
+	return e
+}
+
+// returns the CONFERENCE links , or the meeting links found in the LOCATION and the DESCRIPTION when there are none
+func (e *Event) GetConferences() []*Conference {
+	return e.conferences
+}
+
+// sets the LOCATION in all the languages , the first one is the default
+func (e *Event) SetLocationTexts(locations []*Text) *Event {
+	e.locations = locations
+	return e
+}
+
+// returns the LOCATION in all the languages with their ALTREP
+func (e *Event) GetLocationTexts() []*Text {
+	return e.locations
+}
+
+// returns the location in the language , or the default one when there is none in that language
+func (e *Event) GetLocationFor(language string) string {
+	if t := textFor(e.locations, language); t != nil {
+		return t.GetValue()
+	}
+	return e.location
+}
+
+// sets the URL of the page about the event
+func (e *Event) SetURL(url string) *Event {
+	e.url = url
+	return e
+}
+
+func (e *Event) GetURL() string {
+	return e.url
+}
+
+// sets the priority from 1 ( the highest ) to 9 ( the lowest ) , 0 is undefined
+func (e *Event) SetPriority(priority int) *Event {
+	e.priority = priority
+	return e
+}
+
+func (e *Event) GetPriority() int {
+	return e.priority
+}
+
+func (e *Event) SetCategory(category string) *Event {
+	e.categories = append(e.categories, category)
+	return e
+}
+
+func (e *Event) SetCategories(categories []string) *Event {
+	e.categories = categories
+	return e
+}
+
+func (e *Event) GetCategories() []string {
+	return e.categories
+}
+
+// reports if the event is in the category , the case is ignored
+func (e *Event) HasCategory(category string) bool {
+	for _, c := range e.categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Event) SetComment(comment string) *Event {
+	e.comments = append(e.comments, comment)
+	return e
+}
+
+func (e *Event) SetComments(comments []string) *Event {
+	e.comments = comments
+	return e
+}
+
+func (e *Event) GetComments() []string {
+	return e.comments
+}
+
+// adds a CONTACT , the contact information for the event
+func (e *Event) SetContact(contact string) *Event {
+	e.contacts = append(e.contacts, contact)
+	return e
+}
+
+func (e *Event) SetContacts(contacts []string) *Event {
+	e.contacts = contacts
+	return e
+}
+
+func (e *Event) GetContacts() []string {
+	return e.contacts
+}
+
+// adds a resource like a room or a projector
+func (e *Event) SetResource(resource string) *Event {
+	e.resources = append(e.resources, resource)
+	return e
+}
+
+func (e *Event) SetResources(resources []string) *Event {
+	e.resources = resources
+	return e
+}
+
+func (e *Event) GetResources() []string {
+	return e.resources
+}
+
+func (e *Event) SetAttachment(a *Attachment) *Event {
+	e.attachments = append(e.attachments, a)
+	return e
+}
+
+func (e *Event) SetAttachments(attachments []*Attachment) *Event {
+	e.attachments = attachments
+	return e
+}
+
+func (e *Event) GetAttachments() []*Attachment {
+	return e.attachments
+}
+
+// adds the UID of a related component
+func (e *Event) SetRelatedTo(uid string) *Event {
+	e.relatedTo = append(e.relatedTo, uid)
+	return e
+}
+
+func (e *Event) SetRelatedToList(uids []string) *Event {
+	e.relatedTo = uids
+	return e
+}
+
+// returns the UIDs of the related components
+func (e *Event) GetRelatedTo() []string {
+	return e.relatedTo
+}
+
+// sets the DTSTAMP , the time when the event data was created
+func (e *Event) SetDTStamp(stamp time.Time) *Event {
+	e.stamp = stamp
+	return e
+}
+
+func (e *Event) GetDTStamp() time.Time {
+	return e.stamp
+}
+
+func (e *Event) SetRequestStatus(rs *RequestStatus) *Event {
+	e.requestStatus = append(e.requestStatus, rs)
+	return e
+}
+
+func (e *Event) SetRequestStatuses(statuses []*RequestStatus) *Event {
+	e.requestStatus = statuses
+	return e
+}
+
+func (e *Event) GetRequestStatuses() []*RequestStatus {
+	return e.requestStatus
+}
+
 func (e *Event) SetGeo(geo *Geo) *Event {
 	e.geo = geo
 	return e
@@ -258,6 +680,18 @@ func (e *Event) GetGeo() *Geo {
 	return e.geo
 }
 
+// adds a raw property , the unknown and X- properties are written back as they are
+func (e *Event) SetProperty(p *Property) *Event {
+	e.appendProperty(p)
+	return e
+}
+
+// replaces all the raw properties
+func (e *Event) SetProperties(properties []*Property) *Event {
+	e.replaceProperties(properties)
+	return e
+}
+
 func (e *Event) String() string {
 	from := e.GetStart().Format(YmdHis)
 	to := e.GetEnd().Format(YmdHis)
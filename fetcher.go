@@ -0,0 +1,220 @@
+package ics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fetcher gets the content of the calendars with some url scheme. The parser reads
+// the returned stream while it is downloaded and closes it when it is done
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// FetcherFunc lets an ordinary function be used as Fetcher
+type FetcherFunc func(ctx context.Context, url string) (io.ReadCloser, error)
+
+func (f FetcherFunc) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	return f(ctx, url)
+}
+
+// returns the fetchers used by new parsers , by url scheme
+func defaultFetchers(client *http.Client, timeout time.Duration) map[string]Fetcher {
+	httpFetcher := NewHTTPFetcher(client).SetTimeout(timeout)
+	return map[string]Fetcher{
+		"http":   httpFetcher,
+		"https":  httpFetcher,
+		"webcal": httpFetcher,
+		"file":   NewFileFetcher(),
+	}
+}
+
+// returns the lower case scheme of the url. paths without scheme ( and windows paths like C:\cal.ics ) are files
+func urlScheme(rawURL string) string {
+	i := strings.Index(rawURL, "://")
+	if i <= 1 {
+		return "file"
+	}
+	return strings.ToLower(rawURL[:i])
+}
+
+// HTTPFetcher downloads calendars over http and https. webcal urls are downloaded over https.
+// With cache it sends conditional requests and returns ErrNotModified for the unchanged calendars
+type HTTPFetcher struct {
+	client  *http.Client
+	cache   HTTPCache
+	timeout time.Duration
+}
+
+// creates new HTTPFetcher that uses the client for the requests
+func NewHTTPFetcher(client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFetcher{client: client, timeout: defaultDownloadTimeout}
+}
+
+// sets the max duration of a download , 0 means no limit
+func (f *HTTPFetcher) SetTimeout(timeout time.Duration) *HTTPFetcher {
+	f.timeout = timeout
+	return f
+}
+
+func (f *HTTPFetcher) GetTimeout() time.Duration {
+	return f.timeout
+}
+
+// returns the client used for the requests
+func (f *HTTPFetcher) GetClient() *http.Client {
+	return f.client
+}
+
+// sets the cache for the ETag and Last-Modified of the responses , nil turns off the conditional requests
+func (f *HTTPFetcher) SetCache(cache HTTPCache) *HTTPFetcher {
+	f.cache = cache
+	return f
+}
+
+func (f *HTTPFetcher) GetCache() HTTPCache {
+	return f.cache
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	if urlScheme(rawURL) == "webcal" {
+		rawURL = "https" + rawURL[len("webcal"):]
+	}
+
+	// the download can take as long as the parsing , the timeout ends when the body is closed
+	cancel := context.CancelFunc(func() {})
+	if f.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if f.cache != nil && !IsUnconditional(ctx) {
+		if entry, ok := f.cache.Get(rawURL); ok {
+			if entry.GetETag() != "" {
+				request.Header.Set("If-None-Match", entry.GetETag())
+			}
+			if entry.GetLastModified() != "" {
+				request.Header.Set("If-Modified-Since", entry.GetLastModified())
+			}
+		}
+	}
+	response, err := f.client.Do(request)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		cancel()
+		return nil, ErrNotModified
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("unexpected response %s", response.Status)
+	}
+	if f.cache != nil {
+		etag, lastModified := response.Header.Get("ETag"), response.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			if err := f.cache.Set(rawURL, NewCacheEntry(etag, lastModified)); err != nil {
+				response.Body.Close()
+				cancel()
+				return nil, err
+			}
+		}
+	}
+	return &cancelOnClose{ReadCloser: response.Body, cancel: cancel}, nil
+}
+
+// cancelOnClose is a response body that releases its context when closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// FileFetcher reads calendars from the local file system. It takes file:// urls and plain paths
+type FileFetcher struct{}
+
+// creates new FileFetcher
+func NewFileFetcher() *FileFetcher {
+	return &FileFetcher{}
+}
+
+func (f *FileFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	path := rawURL
+	if urlScheme(rawURL) == "file" && strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		path = u.Path
+	}
+
+	//  check if file exists
+	if !fileExists(path) {
+		return nil, fmt.Errorf("File %s does not exists", path)
+	}
+	return os.Open(path)
+}
+
+// MemoryFetcher serves calendars kept in memory by name. Register it for a scheme like
+// "memory" and send urls like memory://name to the parser
+type MemoryFetcher struct {
+	lock      *sync.RWMutex
+	calendars map[string]string
+}
+
+// creates new empty MemoryFetcher
+func NewMemoryFetcher() *MemoryFetcher {
+	return &MemoryFetcher{lock: new(sync.RWMutex), calendars: make(map[string]string)}
+}
+
+// sets the content of the calendar with the name , the name is the url without the scheme
+func (f *MemoryFetcher) Set(name, content string) *MemoryFetcher {
+	f.lock.Lock()
+	f.calendars[name] = content
+	f.lock.Unlock()
+	return f
+}
+
+// removes the calendar with the name
+func (f *MemoryFetcher) Delete(name string) *MemoryFetcher {
+	f.lock.Lock()
+	delete(f.calendars, name)
+	f.lock.Unlock()
+	return f
+}
+
+func (f *MemoryFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	name := rawURL
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		name = rawURL[i+len("://"):]
+	}
+	f.lock.RLock()
+	content, ok := f.calendars[name]
+	f.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("There is no calendar %s", name)
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
@@ -0,0 +1,302 @@
+package ics
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Period is a time interval of a VFREEBUSY with its FBTYPE like FREE , BUSY ,
+// BUSY-UNAVAILABLE or BUSY-TENTATIVE
+type Period struct {
+	start  time.Time
+	end    time.Time
+	fbType string
+}
+
+func NewPeriod(start, end time.Time, fbType string) *Period {
+	return &Period{start: start, end: end, fbType: fbType}
+}
+
+func (p *Period) GetStart() time.Time {
+	return p.start
+}
+
+func (p *Period) GetEnd() time.Time {
+	return p.end
+}
+
+func (p *Period) GetFbType() string {
+	return p.fbType
+}
+
+// reports if the time is not in the free time of the period
+func (p *Period) IsBusy() bool {
+	return p.fbType != "FREE"
+}
+
+func (p *Period) String() string {
+	return fmt.Sprintf("%s from %s to %s", p.GetFbType(), p.GetStart().Format(YmdHis), p.GetEnd().Format(YmdHis))
+}
+
+// FreeBusy is a VFREEBUSY , the busy time of a calendar user between start and end
+type FreeBusy struct {
+	start      time.Time
+	end        time.Time
+	importedId string
+	organizer  *Attendee
+	attendees  []*Attendee
+	periods    []*Period
+	inCalendar *Calendar
+	propertySet
+}
+
+func NewFreeBusy() *FreeBusy {
+	fb := new(FreeBusy)
+	fb.attendees = []*Attendee{}
+	fb.periods = []*Period{}
+	fb.properties = []*Property{}
+	return fb
+}
+
+func (fb *FreeBusy) SetStart(start time.Time) *FreeBusy {
+	fb.start = start
+	return fb
+}
+
+func (fb *FreeBusy) GetStart() time.Time {
+	return fb.start
+}
+
+func (fb *FreeBusy) SetEnd(end time.Time) *FreeBusy {
+	fb.end = end
+	return fb
+}
+
+func (fb *FreeBusy) GetEnd() time.Time {
+	return fb.end
+}
+
+func (fb *FreeBusy) SetImportedID(id string) *FreeBusy {
+	fb.importedId = id
+	return fb
+}
+
+func (fb *FreeBusy) GetImportedID() string {
+	return fb.importedId
+}
+
+func (fb *FreeBusy) SetOrganizer(a *Attendee) *FreeBusy {
+	fb.organizer = a
+	return fb
+}
+
+func (fb *FreeBusy) GetOrganizer() *Attendee {
+	return fb.organizer
+}
+
+func (fb *FreeBusy) SetAttendee(a *Attendee) *FreeBusy {
+	fb.attendees = append(fb.attendees, a)
+	return fb
+}
+
+func (fb *FreeBusy) SetAttendees(attendees []*Attendee) *FreeBusy {
+	fb.attendees = append(fb.attendees, attendees...)
+	return fb
+}
+
+func (fb *FreeBusy) GetAttendees() []*Attendee {
+	return fb.attendees
+}
+
+// adds a FREEBUSY period
+func (fb *FreeBusy) SetPeriod(period *Period) *FreeBusy {
+	fb.periods = append(fb.periods, period)
+	return fb
+}
+
+func (fb *FreeBusy) SetPeriods(periods []*Period) *FreeBusy {
+	fb.periods = periods
+	return fb
+}
+
+func (fb *FreeBusy) GetPeriods() []*Period {
+	return fb.periods
+}
+
+// returns the periods between start and end that are not busy ordered by time
+func (fb *FreeBusy) GetFreePeriods() []*Period {
+	busy := []*Period{}
+	for _, period := range fb.periods {
+		if period.IsBusy() {
+			busy = append(busy, period)
+		}
+	}
+	busy = mergePeriods(busy)
+
+	free := []*Period{}
+	from := fb.start
+	for _, period := range busy {
+		if period.start.After(from) {
+			free = append(free, NewPeriod(from, period.start, "FREE"))
+		}
+		if period.end.After(from) {
+			from = period.end
+		}
+	}
+	if fb.end.After(from) {
+		free = append(free, NewPeriod(from, fb.end, "FREE"))
+	}
+	return free
+}
+
+// reports if no busy period overlaps the time between start and end
+func (fb *FreeBusy) IsFree(start, end time.Time) bool {
+	for _, period := range fb.periods {
+		if period.IsBusy() && period.start.Before(end) && period.end.After(start) {
+			return false
+		}
+	}
+	return true
+}
+
+func (fb *FreeBusy) SetCalendar(cal *Calendar) *FreeBusy {
+	fb.inCalendar = cal
+	return fb
+}
+
+func (fb *FreeBusy) GetCalendar() *Calendar {
+	return fb.inCalendar
+}
+
+// generates an unique id for the free/busy
+func (fb *FreeBusy) GenerateFreeBusyId() string {
+	toBeHashed := fmt.Sprintf("%s%s%s", fb.GetStart(), fb.GetEnd(), fb.GetImportedID())
+	return fmt.Sprintf("%x", md5.Sum(stringToByte(toBeHashed)))
+}
+
+// adds a raw property , the unknown and X- properties are written back as they are
+func (fb *FreeBusy) SetProperty(p *Property) *FreeBusy {
+	fb.appendProperty(p)
+	return fb
+}
+
+// replaces all the raw properties
+func (fb *FreeBusy) SetProperties(properties []*Property) *FreeBusy {
+	fb.replaceProperties(properties)
+	return fb
+}
+
+func (fb *FreeBusy) String() string {
+	return fmt.Sprintf("FreeBusy from %s to %s with %d periods", fb.GetStart().Format(YmdHis), fb.GetEnd().Format(YmdHis), len(fb.GetPeriods()))
+}
+
+// FreeBusy returns the busy time between from and to. It is derived from the instances of the
+// events that are not TRANSPARENT or CANCELLED and from the busy periods of the VFREEBUSYs in the calendar.
+// The overlapping periods with the same FBTYPE are merged
+func (c *Calendar) FreeBusy(from, to time.Time) *FreeBusy {
+	fb := NewFreeBusy().SetStart(from.UTC()).SetEnd(to.UTC())
+
+	c.lock.Lock()
+	events := make([]*Event, 0, len(c.events))
+	overrides := []*Event{}
+	for i := range c.events {
+		// the instances are returned by the occurrences of the repeating event
+		if c.events[i].generated {
+			continue
+		}
+		// the overrides are added on their own , also the ones without repeating event or matching instance
+		if !c.events[i].GetRecurrenceID().IsZero() {
+			overrides = append(overrides, c.events[i])
+		} else {
+			events = append(events, c.events[i])
+		}
+	}
+	c.lock.Unlock()
+
+	byType := map[string][]*Period{}
+	addPeriod := func(start, end time.Time, fbType string) {
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if start.Before(end) {
+			byType[fbType] = append(byType[fbType], NewPeriod(start.UTC(), end.UTC(), fbType))
+		}
+	}
+
+	addInstance := func(instance *Event) {
+		if instance.GetTransp() == "TRANSPARENT" || instance.GetStatus() == "CANCELLED" {
+			return
+		}
+		fbType := "BUSY"
+		if instance.GetStatus() == "TENTATIVE" {
+			fbType = "BUSY-TENTATIVE"
+		}
+		addPeriod(instance.GetStart(), instance.effectiveEnd(), fbType)
+	}
+
+	for _, event := range events {
+		occurrences := event.Occurrences()
+		for {
+			instance, ok := occurrences.Next()
+			if !ok {
+				break
+			}
+			// the instances come in the order of the times they replace , an override may be moved after to
+			if !instance.GetRecurrenceID().IsZero() {
+				if !instance.GetRecurrenceID().Before(to) {
+					break
+				}
+				continue
+			}
+			if !instance.GetStart().Before(to) {
+				break
+			}
+			addInstance(instance)
+		}
+	}
+	for _, override := range overrides {
+		addInstance(override)
+	}
+	for _, parsed := range c.GetFreeBusies() {
+		for _, period := range parsed.GetPeriods() {
+			if period.IsBusy() {
+				addPeriod(period.GetStart(), period.GetEnd(), period.GetFbType())
+			}
+		}
+	}
+
+	fbTypes := []string{}
+	for fbType := range byType {
+		fbTypes = append(fbTypes, fbType)
+	}
+	sort.Strings(fbTypes)
+	for _, fbType := range fbTypes {
+		fb.periods = append(fb.periods, mergePeriods(byType[fbType])...)
+	}
+	sort.SliceStable(fb.periods, func(i, j int) bool { return fb.periods[i].start.Before(fb.periods[j].start) })
+	return fb
+}
+
+// returns the periods ordered by start with the overlapping and adjacent ones joined
+func mergePeriods(periods []*Period) []*Period {
+	sorted := append([]*Period{}, periods...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+
+	merged := []*Period{}
+	for _, period := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !period.start.After(merged[last].end) {
+			if period.end.After(merged[last].end) {
+				merged[last] = NewPeriod(merged[last].start, period.end, merged[last].fbType)
+			}
+			continue
+		}
+		merged = append(merged, period)
+	}
+	return merged
+}
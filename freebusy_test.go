@@ -0,0 +1,155 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const busyCal = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"DTSTART:20190603T100000Z\r\n" +
+	"DTEND:20190603T103000Z\r\n" +
+	"RRULE:FREQ=DAILY\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"DTSTART:20190604T102000Z\r\n" +
+	"DTEND:20190604T110000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:lunch\r\n" +
+	"DTSTART:20190604T120000Z\r\n" +
+	"DTEND:20190604T130000Z\r\n" +
+	"STATUS:TENTATIVE\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"DTSTART;VALUE=DATE:20190604\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"DTSTART:20190604T150000Z\r\n" +
+	"DTEND:20190604T160000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VFREEBUSY\r\n" +
+	"UID:shared\r\n" +
+	"DTSTART:20190601T000000Z\r\n" +
+	"DTEND:20190608T000000Z\r\n" +
+	"ORGANIZER:mailto:jane@example.com\r\n" +
+	"FREEBUSY;FBTYPE=BUSY-UNAVAILABLE:20190604T170000Z/PT1H,20190604T180000Z/20190604T183000Z\r\n" +
+	"FREEBUSY;FBTYPE=FREE:20190604T080000Z/PT1H\r\n" +
+	"FREEBUSY:nonsense\r\n" +
+	"END:VFREEBUSY\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseFreeBusy(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(busyCal))
+	if len(cal.GetFreeBusies()) != 1 {
+		t.Fatalf("Expected 1 free/busy, got %d", len(cal.GetFreeBusies()))
+	}
+	fb := cal.GetFreeBusies()[0]
+	if fb.GetImportedID() != "shared" || fb.GetOrganizer() == nil || fb.GetOrganizer().GetEmail() != "jane@example.com" {
+		t.Errorf("Unexpected free/busy %s", fb)
+	}
+	periods := fb.GetPeriods()
+	if len(periods) != 3 {
+		t.Fatalf("Expected 3 periods, got %v", periods)
+	}
+	if periods[0].GetFbType() != "BUSY-UNAVAILABLE" || periods[0].GetEnd() != time.Date(2019, 6, 4, 18, 0, 0, 0, time.UTC) || periods[2].IsBusy() {
+		t.Errorf("Unexpected periods %v", periods)
+	}
+	if errs := cal.GetErrors(); len(errs) != 1 || errs[0].GetProperty() != "FREEBUSY" {
+		t.Errorf("Expected error for the invalid period, got %v", errs)
+	}
+}
+
+func TestCalendarFreeBusy(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(busyCal))
+	from := time.Date(2019, 6, 4, 0, 0, 0, 0, time.UTC)
+	fb := cal.FreeBusy(from, from.Add(24*time.Hour))
+
+	expected := []string{
+		"BUSY from 2019-06-04 10:00:00 to 2019-06-04 11:00:00",
+		"BUSY-TENTATIVE from 2019-06-04 12:00:00 to 2019-06-04 13:00:00",
+		"BUSY-UNAVAILABLE from 2019-06-04 17:00:00 to 2019-06-04 18:30:00",
+	}
+	periods := fb.GetPeriods()
+	if len(periods) != len(expected) {
+		t.Fatalf("Expected periods %v, got %v", expected, periods)
+	}
+	for i := range expected {
+		if periods[i].String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], periods[i])
+		}
+	}
+
+	if fb.IsFree(from.Add(10*time.Hour+30*time.Minute), from.Add(12*time.Hour)) || !fb.IsFree(from.Add(11*time.Hour), from.Add(12*time.Hour)) {
+		t.Errorf("Unexpected free time in %v", periods)
+	}
+	free := fb.GetFreePeriods()
+	if len(free) != 4 || !free[0].GetEnd().Equal(from.Add(10*time.Hour)) || !free[3].GetStart().Equal(from.Add(18*time.Hour+30*time.Minute)) {
+		t.Errorf("Unexpected free periods %v", free)
+	}
+
+	// the instances of the repeating event are cut at the bounds
+	fb = cal.FreeBusy(time.Date(2019, 6, 10, 10, 15, 0, 0, time.UTC), time.Date(2019, 6, 10, 12, 0, 0, 0, time.UTC))
+	if len(fb.GetPeriods()) != 1 || fb.GetPeriods()[0].String() != "BUSY from 2019-06-10 10:15:00 to 2019-06-10 10:30:00" {
+		t.Errorf("Unexpected periods %v", fb.GetPeriods())
+	}
+
+	written, err := fb.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !strings.Contains(string(written), "FREEBUSY;FBTYPE=BUSY:20190610T101500Z/20190610T103000Z\r\n") {
+		t.Errorf("Expected the busy period in\n%s", written)
+	}
+	parsed := loadSingleCalendar(t, written)
+	if len(parsed.GetFreeBusies()) != 1 || len(parsed.GetFreeBusies()[0].GetPeriods()) != 1 {
+		t.Errorf("Expected the free/busy to survive writing, got %v", parsed.GetFreeBusies())
+	}
+}
+
+func TestCalendarFreeBusyOverrides(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"DTSTART:20190601T090000Z\r\n"+
+		"DTEND:20190601T100000Z\r\n"+
+		"RRULE:FREQ=DAILY;COUNT=10\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"RECURRENCE-ID:20190602T090000Z\r\n"+
+		"DTSTART:20190702T090000Z\r\n"+
+		"DTEND:20190702T100000Z\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:invitation\r\n"+
+		"RECURRENCE-ID:20190605T140000Z\r\n"+
+		"DTSTART:20190605T150000Z\r\n"+
+		"DTEND:20190605T160000Z\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	// the moved instance does not stop the later ones and the instance without its repeating event is busy
+	fb := cal.FreeBusy(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 6, 15, 0, 0, 0, 0, time.UTC))
+	if len(fb.GetPeriods()) != 10 {
+		t.Errorf("Expected 9 instances and the invitation, got %v", fb.GetPeriods())
+	}
+	if fb.IsFree(time.Date(2019, 6, 5, 15, 0, 0, 0, time.UTC), time.Date(2019, 6, 5, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the invitation busy, got %v", fb.GetPeriods())
+	}
+	if !fb.IsFree(time.Date(2019, 6, 2, 9, 0, 0, 0, time.UTC), time.Date(2019, 6, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the moved instance free, got %v", fb.GetPeriods())
+	}
+
+	fb = cal.FreeBusy(time.Date(2019, 7, 2, 0, 0, 0, 0, time.UTC), time.Date(2019, 7, 3, 0, 0, 0, 0, time.UTC))
+	if len(fb.GetPeriods()) != 1 || fb.GetPeriods()[0].String() != "BUSY from 2019-07-02 09:00:00 to 2019-07-02 10:00:00" {
+		t.Errorf("Expected the moved instance busy, got %v", fb.GetPeriods())
+	}
+}
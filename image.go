@@ -0,0 +1,63 @@
+package ics
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Image is a RFC 7986 IMAGE property , a link to a picture or the picture itself inline
+type Image struct {
+	uri     string
+	data    string
+	fmtType string
+	display string
+}
+
+// creates new image that links to the uri
+func NewImageURI(uri string) *Image {
+	return &Image{uri: uri}
+}
+
+// creates new image with the inline content
+func NewImageData(data []byte) *Image {
+	return &Image{data: base64.StdEncoding.EncodeToString(data)}
+}
+
+// returns the uri of the linked picture , empty for the inline ones
+func (i *Image) GetURI() string {
+	return i.uri
+}
+
+// reports if the picture is inline
+func (i *Image) IsBinary() bool {
+	return i.uri == ""
+}
+
+// returns the decoded inline picture
+func (i *Image) GetData() ([]byte, error) {
+	if !i.IsBinary() {
+		return nil, errors.New("The image is a link to " + i.uri)
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(i.data), ""))
+}
+
+// sets the media type like image/png
+func (i *Image) SetFmtType(fmtType string) *Image {
+	i.fmtType = fmtType
+	return i
+}
+
+func (i *Image) GetFmtType() string {
+	return i.fmtType
+}
+
+// sets how the image is displayed like BADGE , GRAPHIC , FULLSIZE or THUMBNAIL
+func (i *Image) SetDisplay(display string) *Image {
+	i.display = display
+	return i
+}
+
+func (i *Image) GetDisplay() string {
+	return i.display
+}
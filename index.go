@@ -0,0 +1,242 @@
+package ics
+
+import (
+	"time"
+)
+
+// eventIndex is an interval tree of the events , an AVL tree ordered by start where each node
+// knows the latest end in its subtree. The events that overlap a time range are found in
+// O(log n + k) and the next event after a time in O(log n)
+type eventIndex struct {
+	root *indexNode
+	// orders the events with the same start by the time they were added
+	seq uint64
+	// the node of each event , to find it when the event is moved
+	nodes map[*Event]*indexNode
+}
+
+type indexNode struct {
+	event  *Event
+	start  time.Time
+	end    time.Time
+	seq    uint64
+	maxEnd time.Time
+	height int
+	left   *indexNode
+	right  *indexNode
+}
+
+func newEventIndex() *eventIndex {
+	idx := new(eventIndex)
+	idx.nodes = make(map[*Event]*indexNode)
+	return idx
+}
+
+// adds the event , its interval is from its start to its end
+func (idx *eventIndex) add(e *Event) {
+	idx.seq++
+	node := &indexNode{event: e, start: e.GetStart(), end: e.effectiveEnd(), seq: idx.seq, height: 1}
+	node.maxEnd = node.end
+	idx.root = idx.root.insert(node)
+	idx.nodes[e] = node
+}
+
+// removes the event , returns false when it is not in the index
+func (idx *eventIndex) remove(e *Event) bool {
+	node, ok := idx.nodes[e]
+	if !ok {
+		return false
+	}
+	idx.root = idx.root.remove(node)
+	delete(idx.nodes, e)
+	return true
+}
+
+// puts the event at its current start and end , the index keeps the times it had when it was added
+func (idx *eventIndex) move(e *Event) {
+	if idx.remove(e) {
+		idx.add(e)
+	}
+}
+
+// returns the events that overlap the time between from and to ordered by start
+func (idx *eventIndex) overlapping(from, to time.Time) []*Event {
+	return idx.root.overlapping(from, to, []*Event{})
+}
+
+// returns the first event that starts after the time , nil when there is none
+func (idx *eventIndex) next(t time.Time) *Event {
+	var found *indexNode
+	for n := idx.root; n != nil; {
+		if n.start.After(t) {
+			found = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return found.event
+}
+
+// returns the first n events that start after the time ordered by start
+func (idx *eventIndex) startingAfter(t time.Time, n int) []*Event {
+	return idx.root.startingAfter(t, n, []*Event{})
+}
+
+// reports if the node is before the other one in the tree
+func (n *indexNode) less(other *indexNode) bool {
+	if n.start.Equal(other.start) {
+		return n.seq < other.seq
+	}
+	return n.start.Before(other.start)
+}
+
+// reports if the interval of the node overlaps the time between from and to ,
+// the events without duration are matched by their start
+func (n *indexNode) overlaps(from, to time.Time) bool {
+	if !n.end.After(n.start) {
+		return !n.start.Before(from) && n.start.Before(to)
+	}
+	return n.start.Before(to) && n.end.After(from)
+}
+
+func (n *indexNode) overlapping(from, to time.Time, found []*Event) []*Event {
+	// nothing in the subtree ends after from
+	if n == nil || n.maxEnd.Before(from) {
+		return found
+	}
+	found = n.left.overlapping(from, to, found)
+	// the node and its right subtree start after to
+	if !n.start.Before(to) {
+		return found
+	}
+	if n.overlaps(from, to) {
+		found = append(found, n.event)
+	}
+	return n.right.overlapping(from, to, found)
+}
+
+func (n *indexNode) startingAfter(t time.Time, limit int, found []*Event) []*Event {
+	if n == nil || len(found) >= limit {
+		return found
+	}
+	// the left subtree starts before the node
+	if n.start.After(t) {
+		found = n.left.startingAfter(t, limit, found)
+		if len(found) < limit {
+			found = append(found, n.event)
+		}
+	}
+	return n.right.startingAfter(t, limit, found)
+}
+
+// inserts the node in the subtree and returns its balanced root
+func (n *indexNode) insert(node *indexNode) *indexNode {
+	if n == nil {
+		return node
+	}
+	if node.less(n) {
+		n.left = n.left.insert(node)
+	} else {
+		n.right = n.right.insert(node)
+	}
+	return n.balance()
+}
+
+// removes the node from the subtree and returns its balanced root
+func (n *indexNode) remove(node *indexNode) *indexNode {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case node.less(n):
+		n.left = n.left.remove(node)
+	case n.less(node):
+		n.right = n.right.remove(node)
+	default:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		// the node is replaced by the first node of its right subtree
+		first := n.right
+		for first.left != nil {
+			first = first.left
+		}
+		first.right = n.right.removeFirst()
+		first.left = n.left
+		return first.balance()
+	}
+	return n.balance()
+}
+
+// removes the first node of the subtree and returns its balanced root
+func (n *indexNode) removeFirst() *indexNode {
+	if n.left == nil {
+		return n.right
+	}
+	n.left = n.left.removeFirst()
+	return n.balance()
+}
+
+func (n *indexNode) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// recalculates the height and the latest end from the children
+func (n *indexNode) update() {
+	n.height = 1 + n.left.getHeight()
+	if n.right.getHeight() >= n.height {
+		n.height = 1 + n.right.getHeight()
+	}
+	n.maxEnd = n.end
+	for _, child := range []*indexNode{n.left, n.right} {
+		if child != nil && child.maxEnd.After(n.maxEnd) {
+			n.maxEnd = child.maxEnd
+		}
+	}
+}
+
+// rotates the subtree when one of the children is higher by 2 and returns its root
+func (n *indexNode) balance() *indexNode {
+	n.update()
+	switch diff := n.left.getHeight() - n.right.getHeight(); {
+	case diff > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case diff < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *indexNode) rotateLeft() *indexNode {
+	root := n.right
+	n.right = root.left
+	root.left = n
+	n.update()
+	root.update()
+	return root
+}
+
+func (n *indexNode) rotateRight() *indexNode {
+	root := n.left
+	n.left = root.right
+	root.right = n
+	n.update()
+	root.update()
+	return root
+}
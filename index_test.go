@@ -0,0 +1,169 @@
+package ics
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestEventIndex(t *testing.T) {
+	base := time.Date(2019, time.June, 3, 0, 0, 0, 0, time.UTC)
+	random := rand.New(rand.NewSource(1))
+	idx := newEventIndex()
+	events := []*Event{}
+	for i := 0; i < 500; i++ {
+		start := base.Add(time.Duration(random.Intn(60*24)) * time.Minute)
+		// some of the events have no duration
+		end := start.Add(time.Duration(random.Intn(4)*random.Intn(180)) * time.Minute)
+		e := NewEvent().SetStart(start).SetEnd(end)
+		events = append(events, e)
+		idx.add(e)
+	}
+	if height := idx.root.getHeight(); height > 12 {
+		t.Errorf("Expected balanced tree, found height %d", height)
+	}
+
+	for i := 0; i < 200; i++ {
+		from := base.Add(time.Duration(random.Intn(60*26)-60) * time.Minute)
+		to := from.Add(time.Duration(random.Intn(120)) * time.Minute)
+		expected := 0
+		for _, e := range events {
+			if e.GetEnd().After(e.GetStart()) && e.GetStart().Before(to) && e.GetEnd().After(from) ||
+				e.GetEnd().Equal(e.GetStart()) && !e.GetStart().Before(from) && e.GetStart().Before(to) {
+				expected++
+			}
+		}
+		found := idx.overlapping(from, to)
+		if len(found) != expected {
+			t.Errorf("Expected %d events between %s and %s, found %d", expected, from, to, len(found))
+		}
+		for j := 1; j < len(found); j++ {
+			if found[j].GetStart().Before(found[j-1].GetStart()) {
+				t.Errorf("Expected events ordered by start between %s and %s", from, to)
+				break
+			}
+		}
+
+		var next *Event
+		for _, e := range events {
+			if e.GetStart().After(from) && (next == nil || e.GetStart().Before(next.GetStart())) {
+				next = e
+			}
+		}
+		if got := idx.next(from); (got == nil) != (next == nil) || got != nil && !got.GetStart().Equal(next.GetStart()) {
+			t.Errorf("Expected next event after %s to be %v, found %v", from, next, got)
+		}
+	}
+	if next := idx.next(base.AddDate(0, 0, 2)); next != nil {
+		t.Errorf("Expected no event after the last one, found %v", next)
+	}
+
+	// the moved events are found only at their new time
+	for _, e := range events[:250] {
+		e.SetStart(e.GetStart().AddDate(0, 0, 1)).SetEnd(e.GetEnd().AddDate(0, 0, 1))
+		idx.move(e)
+	}
+	if height := idx.root.getHeight(); height > 12 || len(idx.nodes) != 500 {
+		t.Errorf("Expected balanced tree of 500 events, found height %d and %d events", height, len(idx.nodes))
+	}
+	for _, from := range []time.Time{base, base.Add(12 * time.Hour), base.AddDate(0, 0, 1), base.Add(36 * time.Hour)} {
+		to := from.Add(time.Hour)
+		expected := 0
+		for _, e := range events {
+			if e.GetEnd().After(e.GetStart()) && e.GetStart().Before(to) && e.GetEnd().After(from) ||
+				e.GetEnd().Equal(e.GetStart()) && !e.GetStart().Before(from) && e.GetStart().Before(to) {
+				expected++
+			}
+		}
+		if found := idx.overlapping(from, to); len(found) != expected {
+			t.Errorf("Expected %d moved events between %s and %s, found %d", expected, from, to, len(found))
+		}
+	}
+	if idx.remove(NewEvent()) {
+		t.Errorf("Expected no removal of event that is not in the index")
+	}
+}
+
+func TestCalendarEventsBetween(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:sabbatical\r\n"+
+		"DTSTART:20190101T000000Z\r\n"+
+		"DTEND:20210101T000000Z\r\n"+
+		"SUMMARY:Sabbatical\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:call\r\n"+
+		"DTSTART:20190604T140000Z\r\n"+
+		"DTEND:20190604T150000Z\r\n"+
+		"SUMMARY:Call\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:deadline\r\n"+
+		"DTSTART:20190604T153000Z\r\n"+
+		"SUMMARY:Deadline\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:holiday\r\n"+
+		"DTSTART;VALUE=DATE:20190605\r\n"+
+		"SUMMARY:Holiday\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	summaries := func(events []*Event) []string {
+		found := []string{}
+		for _, e := range events {
+			found = append(found, e.GetSummary())
+		}
+		return found
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2019, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		got      []*Event
+		expected []string
+	}{
+		{"overlap", cal.GetEventsBetween(at(4, 14, 0), at(4, 15, 30)), []string{"Sabbatical", "Call"}},
+		{"deadline", cal.GetEventsBetween(at(4, 15, 0), at(4, 16, 0)), []string{"Sabbatical", "Deadline"}},
+		{"at", cal.GetEventsAt(at(4, 14, 30)), []string{"Sabbatical", "Call"}},
+		{"at end", cal.GetEventsAt(at(4, 15, 0)), []string{"Sabbatical"}},
+		{"at start", cal.GetEventsAt(at(4, 15, 30)), []string{"Sabbatical", "Deadline"}},
+		{"whole day", cal.GetEventsAt(at(5, 23, 59)), []string{"Sabbatical", "Holiday"}},
+		{"by date", mustEventsByDate(t, cal, at(4, 0, 0)), []string{"Sabbatical", "Call", "Deadline"}},
+	}
+	for _, tc := range testCases {
+		got := summaries(tc.got)
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: Expected %v, found %v", tc.name, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: Expected %v, found %v", tc.name, tc.expected, got)
+				break
+			}
+		}
+	}
+
+	if next := cal.GetNextEvent(at(4, 14, 0)); next == nil || next.GetSummary() != "Deadline" {
+		t.Errorf("Expected the deadline next, found %v", next)
+	}
+	if next := cal.GetNextEvent(at(6, 0, 0)); next != nil {
+		t.Errorf("Expected no next event, found %v", next)
+	}
+	if days := len(cal.GetEventsByDates()); days != 731 {
+		t.Errorf("Expected 731 days with events, found %d", days)
+	}
+}
+
+func mustEventsByDate(t *testing.T, cal *Calendar, day time.Time) []*Event {
+	t.Helper()
+	events, err := cal.GetEventsByDate(day)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	return events
+}
@@ -0,0 +1,234 @@
+package ics
+
+import (
+	"crypto/md5"
+	"fmt"
+	"time"
+)
+
+// Journal is a VJOURNAL , a note attached to a date
+type Journal struct {
+	start        time.Time
+	startTZID    string
+	wholeDay     bool
+	created      time.Time
+	modified     time.Time
+	importedId   string
+	id           string
+	status       string
+	class        string
+	sequence     int
+	summary      string
+	descriptions []string
+	attachments  []*Attachment
+	categories   []string
+	organizer    *Attendee
+	inCalendar   *Calendar
+	propertySet
+}
+
+func NewJournal() *Journal {
+	j := new(Journal)
+	j.descriptions = []string{}
+	j.attachments = []*Attachment{}
+	j.categories = []string{}
+	j.properties = []*Property{}
+	return j
+}
+
+func (j *Journal) SetStart(start time.Time) *Journal {
+	j.start = start
+	return j
+}
+
+func (j *Journal) GetStart() time.Time {
+	return j.start
+}
+
+func (j *Journal) SetStartTZID(tzid string) *Journal {
+	j.startTZID = tzid
+	return j
+}
+
+func (j *Journal) GetStartTZID() string {
+	return j.startTZID
+}
+
+// sets if the start is a date without time
+func (j *Journal) SetWholeDay(wholeDay bool) *Journal {
+	j.wholeDay = wholeDay
+	return j
+}
+
+func (j *Journal) IsWholeDay() bool {
+	return j.wholeDay
+}
+
+func (j *Journal) SetCreated(created time.Time) *Journal {
+	j.created = created
+	return j
+}
+
+func (j *Journal) GetCreated() time.Time {
+	return j.created
+}
+
+func (j *Journal) SetLastModified(modified time.Time) *Journal {
+	j.modified = modified
+	return j
+}
+
+func (j *Journal) GetLastModified() time.Time {
+	return j.modified
+}
+
+func (j *Journal) SetImportedID(id string) *Journal {
+	j.importedId = id
+	return j
+}
+
+func (j *Journal) GetImportedID() string {
+	return j.importedId
+}
+
+func (j *Journal) SetID(id string) *Journal {
+	j.id = id
+	return j
+}
+
+func (j *Journal) GetID() string {
+	return j.id
+}
+
+// sets the status like DRAFT , FINAL or CANCELLED
+func (j *Journal) SetStatus(status string) *Journal {
+	j.status = status
+	return j
+}
+
+func (j *Journal) GetStatus() string {
+	return j.status
+}
+
+func (j *Journal) SetClass(class string) *Journal {
+	j.class = class
+	return j
+}
+
+func (j *Journal) GetClass() string {
+	return j.class
+}
+
+func (j *Journal) SetSequence(sq int) *Journal {
+	j.sequence = sq
+	return j
+}
+
+func (j *Journal) GetSequence() int {
+	return j.sequence
+}
+
+func (j *Journal) SetSummary(summary string) *Journal {
+	j.summary = summary
+	return j
+}
+
+func (j *Journal) GetSummary() string {
+	return j.summary
+}
+
+// adds a description , a journal may have many of them
+func (j *Journal) SetDescription(description string) *Journal {
+	j.descriptions = append(j.descriptions, description)
+	return j
+}
+
+func (j *Journal) SetDescriptions(descriptions []string) *Journal {
+	j.descriptions = descriptions
+	return j
+}
+
+// returns the first description
+func (j *Journal) GetDescription() string {
+	if len(j.descriptions) == 0 {
+		return ""
+	}
+	return j.descriptions[0]
+}
+
+func (j *Journal) GetDescriptions() []string {
+	return j.descriptions
+}
+
+func (j *Journal) SetAttachment(a *Attachment) *Journal {
+	j.attachments = append(j.attachments, a)
+	return j
+}
+
+func (j *Journal) SetAttachments(attachments []*Attachment) *Journal {
+	j.attachments = attachments
+	return j
+}
+
+func (j *Journal) GetAttachments() []*Attachment {
+	return j.attachments
+}
+
+func (j *Journal) SetCategory(category string) *Journal {
+	j.categories = append(j.categories, category)
+	return j
+}
+
+func (j *Journal) SetCategories(categories []string) *Journal {
+	j.categories = categories
+	return j
+}
+
+func (j *Journal) GetCategories() []string {
+	return j.categories
+}
+
+func (j *Journal) SetOrganizer(a *Attendee) *Journal {
+	j.organizer = a
+	return j
+}
+
+func (j *Journal) GetOrganizer() *Attendee {
+	return j.organizer
+}
+
+func (j *Journal) SetCalendar(cal *Calendar) *Journal {
+	j.inCalendar = cal
+	return j
+}
+
+func (j *Journal) GetCalendar() *Calendar {
+	return j.inCalendar
+}
+
+// generates an unique id for the journal
+func (j *Journal) GenerateJournalId() string {
+	var toBeHashed string
+	if j.GetImportedID() != "" {
+		toBeHashed = fmt.Sprintf("%s%s", j.GetStart(), j.GetImportedID())
+	} else {
+		toBeHashed = fmt.Sprintf("%s%s%v", j.GetStart(), j.GetSummary(), j.GetDescriptions())
+	}
+	return fmt.Sprintf("%x", md5.Sum(stringToByte(toBeHashed)))
+}
+
+// adds a raw property , the unknown and X- properties are written back as they are
+func (j *Journal) SetProperty(p *Property) *Journal {
+	j.appendProperty(p)
+	return j
+}
+
+// replaces all the raw properties
+func (j *Journal) SetProperties(properties []*Property) *Journal {
+	j.replaceProperties(properties)
+	return j
+}
+
+func (j *Journal) String() string {
+	return fmt.Sprintf("Journal(%s) from %s about %s", j.GetStatus(), j.GetStart().Format(YmdHis), j.GetSummary())
+}
@@ -0,0 +1,78 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+const notesCal = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VJOURNAL\r\n" +
+	"UID:standup-notes\r\n" +
+	"DTSTART;VALUE=DATE:20190603\r\n" +
+	"SUMMARY:Standup notes\r\n" +
+	"DESCRIPTION:Release is on track\r\n" +
+	"DESCRIPTION:Docs need review\\, again\r\n" +
+	"CATEGORIES:work,release\\, 1.0\r\n" +
+	"CATEGORIES:notes\r\n" +
+	"ATTACH:https://example.com/agenda.pdf\r\n" +
+	"ATTACH;FMTTYPE=text/plain;ENCODING=BASE64;VALUE=BINARY:aGVsbG8=\r\n" +
+	"END:VJOURNAL\r\n" +
+	"BEGIN:VJOURNAL\r\n" +
+	"UID:retro\r\n" +
+	"DTSTART:20190603T150000Z\r\n" +
+	"SUMMARY:Retro\r\n" +
+	"END:VJOURNAL\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseJournals(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(notesCal))
+	if len(cal.GetJournals()) != 2 {
+		t.Fatalf("Expected 2 journals, got %d", len(cal.GetJournals()))
+	}
+
+	journal, err := cal.GetJournalByImportedID("standup-notes")
+	if err != nil {
+		t.Fatalf("Failed to get journal by id with error %s", err)
+	}
+	if !journal.IsWholeDay() || journal.GetSummary() != "Standup notes" {
+		t.Errorf("Unexpected journal %s", journal)
+	}
+	descriptions := journal.GetDescriptions()
+	if len(descriptions) != 2 || descriptions[1] != "Docs need review, again" {
+		t.Errorf("Unexpected descriptions %q", descriptions)
+	}
+	categories := journal.GetCategories()
+	if len(categories) != 3 || categories[1] != "release, 1.0" || categories[2] != "notes" {
+		t.Errorf("Unexpected categories %q", categories)
+	}
+
+	attachments := journal.GetAttachments()
+	if len(attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(attachments))
+	}
+	if attachments[0].IsBinary() || attachments[0].GetURI() != "https://example.com/agenda.pdf" {
+		t.Errorf("Expected link attachment, got %v", attachments[0])
+	}
+	data, err := attachments[1].GetData()
+	if err != nil || string(data) != "hello" || attachments[1].GetFmtType() != "text/plain" {
+		t.Errorf("Expected inline text/plain attachment hello, got %q %s ( %v )", data, attachments[1].GetFmtType(), err)
+	}
+
+	journals, err := cal.GetJournalsByDate(time.Date(2019, 6, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil || len(journals) != 2 {
+		t.Errorf("Expected 2 journals on 2019-06-03, got %v ( %v )", journals, err)
+	}
+	if _, err := cal.GetJournalsByDate(time.Date(2019, 6, 4, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Expected no journals on 2019-06-04")
+	}
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	parsed := loadSingleCalendar(t, written)
+	rewritten, _ := parsed.GetJournalByImportedID("standup-notes")
+	if rewritten == nil || len(rewritten.GetDescriptions()) != 2 || len(rewritten.GetCategories()) != 3 || len(rewritten.GetAttachments()) != 2 {
+		t.Errorf("Expected the journal to survive writing, got %v", rewritten)
+	}
+}
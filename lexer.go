@@ -0,0 +1,484 @@
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// contentLine is a single unfolded RFC 5545 content line split into
+// its name, parameters and value
+type contentLine struct {
+	name   string
+	params []*contentParam
+	value  string
+	line   int
+	// the writer the value was streamed to instead of being kept in value
+	streamed io.WriteCloser
+}
+
+// contentParam is a property parameter with its (already unquoted) values
+type contentParam struct {
+	name   string
+	values []string
+}
+
+// returns the first value of the parameter or an empty string
+func (cl *contentLine) param(name string) string {
+	values := cl.paramValues(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// returns all the values of the parameter
+func (cl *contentLine) paramValues(name string) []string {
+	name = strings.ToUpper(name)
+	for _, p := range cl.params {
+		if p.name == name {
+			return p.values
+		}
+	}
+	return nil
+}
+
+// lexer reads an iCalendar stream and returns it as unfolded content lines
+type lexer struct {
+	reader *bufio.Reader
+	// number of the last physical line read
+	line int
+	// called with the lines which value is longer than streamLimit , when it returns a writer
+	// the value is written to it and not kept in the content line
+	stream      func(cl *contentLine) io.WriteCloser
+	streamLimit int
+}
+
+func newLexer(r io.Reader) *lexer {
+	return &lexer{reader: bufio.NewReader(r)}
+}
+
+// returns the next content line in the stream or io.EOF when there are no more
+func (l *lexer) next() (*contentLine, error) {
+	for {
+		raw, start, streamed, err := l.unfold()
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		cl, err := parseContentLine(raw, start)
+		if cl != nil {
+			cl.streamed = streamed
+		}
+		return cl, err
+	}
+}
+
+// reads a single logical line, joining the folded continuation lines.
+// returns the line and the number of the physical line it started at ,
+// for the streamed lines the value is not in the line but written to the returned writer
+func (l *lexer) unfold() (string, int, io.WriteCloser, error) {
+	first, err := l.readPhysical()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	start := l.line
+	if start == 1 {
+		first = strings.TrimPrefix(first, "\ufeff")
+	}
+
+	// END lines are never folded by the producers , not looking for a continuation
+	// lets the component be handled without waiting for more data from the stream
+	if isEndLine(first) {
+		return first, start, nil, nil
+	}
+
+	var sb strings.Builder
+	var streamed io.WriteCloser
+	// the stream is asked once per line , when the value becomes longer than the limit
+	asked := false
+	// the length of the name and the parameters , -1 until they are read
+	headerLen := -1
+	add := func(part string) {
+		if streamed != nil {
+			io.WriteString(streamed, part)
+			return
+		}
+		sb.WriteString(part)
+		if l.stream == nil || asked {
+			return
+		}
+		// the parameters end with a colon , so they are parsed again only when the part has one
+		if headerLen < 0 && strings.ContainsRune(part, ':') {
+			if cl, err := parseContentLine(sb.String(), start); err == nil {
+				headerLen = sb.Len() - len(cl.value)
+			}
+		}
+		if headerLen >= 0 && sb.Len()-headerLen > l.streamLimit {
+			asked = true
+			var header string
+			if header, streamed = l.startStream(sb.String(), start); streamed != nil {
+				sb.Reset()
+				sb.WriteString(header)
+			}
+		}
+	}
+
+	add(first)
+	for {
+		b, err := l.reader.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t') {
+			break
+		}
+		continuation, err := l.readPhysical()
+		if err != nil && err != io.EOF {
+			return "", 0, nil, err
+		}
+		// the first white space char is part of the folding, not the value
+		add(continuation[1:])
+	}
+	if streamed != nil {
+		// the errors of the writer are reported by the parser
+		streamed.Close()
+	}
+	return sb.String(), start, streamed, nil
+}
+
+// asks for a writer for the value of the long line , the line read so far is raw.
+// returns the line without the value and the writer , that already received the value read so far
+func (l *lexer) startStream(raw string, start int) (string, io.WriteCloser) {
+	cl, err := parseContentLine(raw, start)
+	if err != nil {
+		return "", nil
+	}
+	w := l.stream(cl)
+	if w == nil {
+		return "", nil
+	}
+	io.WriteString(w, cl.value)
+	return raw[:len(raw)-len(cl.value)], w
+}
+
+// reports if the line closes a component
+func isEndLine(line string) bool {
+	if len(line) < 5 || !strings.EqualFold(line[:4], "END:") {
+		return false
+	}
+	for _, r := range line[4:] {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// reads one physical line without the line break
+func (l *lexer) readPhysical() (string, error) {
+	s, err := l.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || s == "") {
+		return "", err
+	}
+	l.line++
+	return strings.TrimRight(s, "\r\n"), nil
+}
+
+// splits an unfolded content line to name, parameters and value
+//   contentline = name *(";" param ) ":" value
+func parseContentLine(raw string, line int) (*contentLine, error) {
+	cl := &contentLine{line: line}
+
+	i := strings.IndexAny(raw, ";:")
+	if i <= 0 {
+		return nil, newSyntaxError(line, "invalid content line %q", raw)
+	}
+	cl.name = strings.ToUpper(strings.TrimSpace(raw[:i]))
+
+	for raw[i] == ';' {
+		i++
+		eq := strings.IndexByte(raw[i:], '=')
+		if eq < 0 {
+			return nil, newSyntaxError(line, "parameter without value in %s", cl.name)
+		}
+		param := &contentParam{name: strings.ToUpper(raw[i : i+eq])}
+		i += eq + 1
+
+		// comma separated list of values , each of them may be quoted
+		for {
+			var value string
+			if i < len(raw) && raw[i] == '"' {
+				end := strings.IndexByte(raw[i+1:], '"')
+				if end < 0 {
+					return nil, newSyntaxError(line, "unterminated quoted parameter %s in %s", param.name, cl.name)
+				}
+				value = raw[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				end := strings.IndexAny(raw[i:], ",;:")
+				if end < 0 {
+					return nil, newSyntaxError(line, "missing value in %s", cl.name)
+				}
+				value = raw[i : i+end]
+				i += end
+			}
+			param.values = append(param.values, decodeParamValue(value))
+
+			if i >= len(raw) {
+				return nil, newSyntaxError(line, "missing value in %s", cl.name)
+			}
+			if raw[i] != ',' {
+				break
+			}
+			i++
+		}
+		cl.params = append(cl.params, param)
+
+		if raw[i] != ';' && raw[i] != ':' {
+			return nil, newSyntaxError(line, "unexpected %q after parameter %s in %s", raw[i], param.name, cl.name)
+		}
+	}
+
+	cl.value = raw[i+1:]
+	return cl, nil
+}
+
+// decodes the RFC 6868 caret escapes of a parameter value
+func decodeParamValue(value string) string {
+	if !strings.Contains(value, "^") {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '^' && i+1 < len(value) {
+			switch value[i+1] {
+			case 'n', 'N':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case '\'':
+				sb.WriteByte('"')
+				i++
+				continue
+			case '^':
+				sb.WriteByte('^')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+// decodes the backslash escapes of a TEXT value
+func unescapeText(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n', 'N':
+				sb.WriteByte('\n')
+			default:
+				// \\ , \; and \, are the char itself
+				sb.WriteByte(value[i])
+			}
+			continue
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+// splits a list of TEXT values at the commas that are not escaped , the values are unescaped
+func splitTextList(value string) []string {
+	return splitText(value, ',')
+}
+
+// splits TEXT value at the separators that are not escaped , the parts are unescaped
+func splitText(value string, sep byte) []string {
+	values := []string{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case sep:
+			values = append(values, unescapeText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeText(value[start:]))
+}
+
+// component is a BEGIN/END block with its properties and nested components
+type component struct {
+	name       string
+	properties []*contentLine
+	components []*component
+	line       int
+}
+
+// returns the first property with the given name or nil
+func (c *component) property(name string) *contentLine {
+	for _, prop := range c.properties {
+		if prop.name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// returns all the properties with the given name
+func (c *component) propertiesByName(name string) []*contentLine {
+	props := []*contentLine{}
+	for _, prop := range c.properties {
+		if prop.name == name {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// returns the value of the first property with the given name
+func (c *component) value(name string) string {
+	prop := c.property(name)
+	if prop == nil {
+		return ""
+	}
+	return prop.value
+}
+
+// returns the nested components with the given name
+func (c *component) componentsByName(name string) []*component {
+	comps := []*component{}
+	for _, comp := range c.components {
+		if comp.name == name {
+			comps = append(comps, comp)
+		}
+	}
+	return comps
+}
+
+// decoder reads an iCalendar stream component by component. Only the properties of
+// the open VCALENDAR and the component that is currently read are kept in memory
+type decoder struct {
+	lexer *lexer
+	// the open VCALENDAR , its nested components are not attached to it
+	calendar *component
+	// the open components under the calendar
+	stack []*component
+	// errors for the malformed lines that were skipped
+	errs []error
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{lexer: newLexer(r)}
+}
+
+// returns the next completed component that is a direct child of a VCALENDAR (or a top level one),
+// or the VCALENDAR itself when it ends. io.EOF is returned at the end of the stream ,
+// components left open at that point are returned as if they were closed
+func (d *decoder) next() (*component, error) {
+	for {
+		cl, err := d.lexer.next()
+		if err == io.EOF {
+			return d.closeAtEOF()
+		}
+		if err != nil {
+			// a broken content line can be skipped , a broken stream can not
+			if _, ok := err.(*syntaxError); !ok {
+				return nil, err
+			}
+			d.errs = append(d.errs, err)
+			continue
+		}
+
+		switch cl.name {
+		case "BEGIN":
+			comp := &component{name: strings.ToUpper(cl.value), line: cl.line}
+			if comp.name == "VCALENDAR" && len(d.stack) == 0 {
+				d.calendar = comp
+				continue
+			}
+			if len(d.stack) > 0 {
+				parent := d.stack[len(d.stack)-1]
+				parent.components = append(parent.components, comp)
+			}
+			d.stack = append(d.stack, comp)
+		case "END":
+			name := strings.ToUpper(cl.value)
+			// find the matching BEGIN , closing everything opened after it
+			i := len(d.stack) - 1
+			for i >= 0 && d.stack[i].name != name {
+				i--
+			}
+			if i < 0 {
+				if name == "VCALENDAR" && d.calendar != nil {
+					calendar := d.calendar
+					d.calendar = nil
+					return calendar, nil
+				}
+				d.errs = append(d.errs, newSyntaxError(cl.line, "END:%s without BEGIN", name))
+				continue
+			}
+			if i != len(d.stack)-1 {
+				d.errs = append(d.errs, newSyntaxError(cl.line, "END:%s closes unterminated %s", name, d.stack[len(d.stack)-1].name))
+			}
+			closed := d.stack[i]
+			d.stack = d.stack[:i]
+			if i == 0 {
+				return closed, nil
+			}
+		default:
+			if len(d.stack) > 0 {
+				current := d.stack[len(d.stack)-1]
+				current.properties = append(current.properties, cl)
+			} else if d.calendar != nil {
+				d.calendar.properties = append(d.calendar.properties, cl)
+			}
+			// properties outside of any component are ignored
+		}
+	}
+}
+
+// returns what is still open when the stream ends
+func (d *decoder) closeAtEOF() (*component, error) {
+	if len(d.stack) > 0 {
+		closed := d.stack[0]
+		d.stack = nil
+		return closed, nil
+	}
+	if d.calendar != nil {
+		calendar := d.calendar
+		d.calendar = nil
+		return calendar, nil
+	}
+	return nil, io.EOF
+}
+
+// returns the errors for the skipped lines since the last call
+func (d *decoder) takeErrors() []error {
+	errs := d.errs
+	d.errs = nil
+	return errs
+}
+
+// syntaxError describes a malformed content line
+type syntaxError struct {
+	line int
+	msg  string
+}
+
+func newSyntaxError(line int, format string, args ...interface{}) *syntaxError {
+	return &syntaxError{line: line, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
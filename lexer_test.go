@@ -0,0 +1,94 @@
+package ics
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLexerUnfoldsLines(t *testing.T) {
+	l := newLexer(strings.NewReader("DESCRIPTION:first \r\n part\r\n\t second part\r\nSUMMARY:next\r\n"))
+
+	cl, err := l.next()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cl.value != "first part second part" {
+		t.Errorf("Expected unfolded value %q, got %q", "first part second part", cl.value)
+	}
+
+	cl, err = l.next()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cl.name != "SUMMARY" || cl.line != 4 {
+		t.Errorf("Expected SUMMARY on line 4, got %s on line %d", cl.name, cl.line)
+	}
+}
+
+func TestContentLineQuotedParams(t *testing.T) {
+	cl, err := parseContentLine(`attendee;CN="Doe; John: Jr.";ROLE=REQ-PARTICIPANT;DELEGATED-TO="mailto:a@x.com","mailto:b@x.com":mailto:john@x.com`, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cl.name != "ATTENDEE" {
+		t.Errorf("Expected name ATTENDEE, got %s", cl.name)
+	}
+	if cl.param("CN") != "Doe; John: Jr." {
+		t.Errorf("Expected CN %q, got %q", "Doe; John: Jr.", cl.param("CN"))
+	}
+	if cl.param("role") != "REQ-PARTICIPANT" {
+		t.Errorf("Expected ROLE %q, got %q", "REQ-PARTICIPANT", cl.param("role"))
+	}
+	if len(cl.paramValues("DELEGATED-TO")) != 2 {
+		t.Errorf("Expected 2 DELEGATED-TO values, got %v", cl.paramValues("DELEGATED-TO"))
+	}
+	if cl.value != "mailto:john@x.com" {
+		t.Errorf("Expected value %q, got %q", "mailto:john@x.com", cl.value)
+	}
+}
+
+func TestContentLineErrors(t *testing.T) {
+	for _, raw := range []string{"NOCOLON", ";X=1:value", `X;CN="open:value`, "X;CN:value"} {
+		if _, err := parseContentLine(raw, 3); err == nil {
+			t.Errorf("Expected error for %q", raw)
+		}
+	}
+}
+
+func TestDecoderIgnoresNamesInValues(t *testing.T) {
+	data := "BEGIN:VCALENDAR\nX-WR-CALNAME:Cal\nBEGIN:VEVENT\nDESCRIPTION:the agenda\n SUMMARY:not a summary\nSUMMARY:Real\nBEGIN:VALARM\nACTION:DISPLAY\nEND:VALARM\nEND:VEVENT\nEND:VCALENDAR\n"
+	d := newDecoder(strings.NewReader(data))
+
+	event, err := d.next()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if event.name != "VEVENT" || len(event.components) != 1 {
+		t.Fatalf("Expected VEVENT with 1 nested component, got %s with %d", event.name, len(event.components))
+	}
+	if d.calendar == nil || d.calendar.value("X-WR-CALNAME") != "Cal" {
+		t.Errorf("Expected the open calendar to have its properties")
+	}
+	if event.value("SUMMARY") != "Real" {
+		t.Errorf("Expected summary %q, got %q", "Real", event.value("SUMMARY"))
+	}
+	if event.value("DESCRIPTION") != "the agendaSUMMARY:not a summary" {
+		t.Errorf("Unexpected description %q", event.value("DESCRIPTION"))
+	}
+
+	calendar, err := d.next()
+	if err != nil || calendar.name != "VCALENDAR" {
+		t.Fatalf("Expected the end of the calendar, got %v %v", calendar, err)
+	}
+	if len(calendar.components) != 0 {
+		t.Errorf("Expected the calendar not to keep its events, got %d", len(calendar.components))
+	}
+
+	if _, err := d.next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+	if errs := d.takeErrors(); len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,63 @@
+package ics
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// Option configures a Parser created with New or NewWithContext
+type Option func(p *Parser)
+
+// adds to the calendars the instances of the repeating events as new events ,
+// up to the number set with WithMaxRepeats per event
+func WithRecurrenceExpansion(expand bool) Option {
+	return func(p *Parser) {
+		p.expandRecurrences = expand
+	}
+}
+
+// sets the max number of instances of a repeating event added to the calendar and returned by the queries without end
+func WithMaxRepeats(n int) Option {
+	return func(p *Parser) {
+		p.maxRepeats = n
+	}
+}
+
+// sets the client used to download the http , https and webcal urls
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Parser) {
+		p.httpClient = client
+	}
+}
+
+// sets the max duration of a calendar download , 0 means no limit
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(p *Parser) {
+		p.downloadTimeout = timeout
+	}
+}
+
+// adds Windows time zone names to the known ones , the given names win
+func WithWindowsZones(zones map[string]string) Option {
+	return func(p *Parser) {
+		p.windowsZones = mergeZones(p.windowsZones, zones)
+	}
+}
+
+// sends the events and todos only to the output chans , they are not kept in their calendars and
+// the repeating events are not expanded. The parsing waits while the consumer does not read ,
+// so both the output chan and the todo output chan must be read until the parsing ends
+func WithStreaming(streaming bool) Option {
+	return func(p *Parser) {
+		p.streaming = streaming
+	}
+}
+
+// streams the big inline attachments to the writers returned by open , see Parser.SetAttachmentWriter
+func WithAttachmentWriter(limit int, open func(a *Attachment) (io.Writer, error)) Option {
+	return func(p *Parser) {
+		p.attachmentLimit = limit
+		p.openAttachment = open
+	}
+}
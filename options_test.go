@@ -0,0 +1,95 @@
+package ics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParserOptions(t *testing.T) {
+	weekly := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:weekly\r\n" +
+		"DTSTART;TZID=Planet Time:20190603T090000\r\n" +
+		"DTEND;TZID=Planet Time:20190603T100000\r\n" +
+		"RRULE:FREQ=WEEKLY\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	// the parsers with different options do not change each other
+	var wg sync.WaitGroup
+	counts := make([]int, 3)
+	for i, options := range [][]Option{
+		{},
+		{WithRecurrenceExpansion(true), WithMaxRepeats(3)},
+		{WithRecurrenceExpansion(true), WithMaxRepeats(50)},
+	} {
+		wg.Add(1)
+		go func(i int, options []Option) {
+			defer wg.Done()
+			parser := New(options...)
+			parser.Load(weekly)
+			calendars, _ := parser.GetCalendars()
+			counts[i] = len(calendars[0].GetEvents())
+		}(i, options)
+	}
+	wg.Wait()
+	if counts[0] != 1 || counts[1] != 4 || counts[2] != 51 {
+		t.Errorf("Expected 1 , 4 and 51 events , found %v", counts)
+	}
+
+	if _, err := time.LoadLocation("Europe/Sofia"); err == nil {
+		cal := loadSingleCalendar(t, []byte(weekly), WithWindowsZones(map[string]string{"Planet Time": "Europe/Sofia"}))
+		event, _ := cal.GetEventByImportedID("weekly")
+		if _, offset := event.GetStart().Zone(); offset != 3*3600 || len(cal.GetErrors()) != 0 {
+			t.Errorf("Expected the start in Sofia , found %s with errors %v", event.GetStart(), cal.GetErrors())
+		}
+		if _, ok := defaultWindowsZones["Planet Time"]; ok {
+			t.Errorf("Expected the package time zones unchanged")
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Client") != "custom" {
+			http.Error(w, "unknown client", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/slow.ics" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(weekly))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: headerTransport{"X-Client", "custom"}}
+
+	planet := WithWindowsZones(map[string]string{"Planet Time": "UTC"})
+	parser := New(WithHTTPClient(client), WithDownloadTimeout(50*time.Millisecond), WithMaxRepeats(2), planet)
+	input := parser.GetInputChan()
+	input <- server.URL + "/cal.ics"
+	input <- server.URL + "/slow.ics"
+	parser.Wait()
+	if errs := parser.GetErrorsForURL(server.URL + "/cal.ics"); len(errs) != 0 {
+		t.Errorf("Unexpected errors with the custom client %v", errs)
+	}
+	if errs := parser.GetErrorsForURL(server.URL + "/slow.ics"); len(errs) != 1 || !strings.Contains(errs[0].Error(), "deadline") {
+		t.Errorf("Expected timeout for the slow calendar , found %v", errs)
+	}
+	if count := len(parser.Query().Where(func(e *Event) bool { return e.GetCalendar().GetUrl() == server.URL+"/cal.ics" }).Events()); count != 2 {
+		t.Errorf("Expected 2 instances limited by the max repeats of the parser , found %d", count)
+	}
+}
+
+// headerTransport adds a header to the requests
+type headerTransport struct {
+	name  string
+	value string
+}
+
+func (h headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(h.name, h.value)
+	return http.DefaultTransport.RoundTrip(r)
+}
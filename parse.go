@@ -1,11 +1,11 @@
 package ics
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"regexp"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,94 +14,161 @@ import (
 	duration "github.com/channelmeter/iso8601duration"
 )
 
-func init() {
-	mutex = new(sync.Mutex)
-	DeleteTempFiles = true
-	FilePath = "tmp/"
-	RepeatRuleApply = false
-	MaxRepeats = 10
+type Parser struct {
+	inputChan        chan string
+	outputChan       chan *Event
+	bufferedChan     chan *Event
+	todoOutputChan   chan *Todo
+	bufferedTodoChan chan *Todo
+	errorsOccured    []error
+	parsedCalendars  []*Calendar
+	parsedEvents     []*Event
+	parsedTodos      []*Todo
+	statusCalendars  int
+	wg               *sync.WaitGroup
+	// guards the errors , the parsed calendars and the status of the parser
+	lock     *sync.Mutex
+	fetchers map[string]Fetcher
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// the calendars parsed from each url , used when the url is not modified
+	calendarsByURL map[string][]*Calendar
+	stats          Stats
+	// receives once every url sent before to the input chan is counted in wg
+	barrier chan struct{}
+	// closed when the goroutines started by the parser have returned
+	inputStopped  chan struct{}
+	outputStopped chan struct{}
+	// opens the writers of the inline attachments longer than attachmentLimit
+	openAttachment  func(a *Attachment) (io.Writer, error)
+	attachmentLimit int
+	// the configuration set by the options , it does not change after the parser is created
+	expandRecurrences bool
+	maxRepeats        int
+	httpClient        *http.Client
+	downloadTimeout   time.Duration
+	windowsZones      map[string]string
+	streaming         bool
 }
 
-type Parser struct {
-	inputChan       chan string
-	outputChan      chan *Event
-	bufferedChan    chan *Event
-	errorsOccured   []error
-	parsedCalendars []*Calendar
-	parsedEvents    []*Event
-	statusCalendars int
-	wg              *sync.WaitGroup
-}
-
-// creates new parser
-func New() *Parser {
+// the number of events and todos buffered for the output chans in streaming mode
+const streamingBuffer = 16
+
+// creates new parser configured by the options like New(WithRecurrenceExpansion(true), WithMaxRepeats(500))
+func New(options ...Option) *Parser {
+	return NewWithContext(context.Background(), options...)
+}
+
+// creates new parser that stops when the context is done or Close is called.
+// The downloads in progress are cancelled and the output chan is closed
+func NewWithContext(ctx context.Context, options ...Option) *Parser {
 	p := new(Parser)
+	p.maxRepeats = defaultMaxRepeats
+	p.httpClient = http.DefaultClient
+	p.downloadTimeout = defaultDownloadTimeout
+	p.windowsZones = defaultWindowsZones
+	for _, option := range options {
+		option(p)
+	}
+
 	p.inputChan = make(chan string)
 	p.outputChan = make(chan *Event)
 	p.bufferedChan = make(chan *Event)
+	p.todoOutputChan = make(chan *Todo)
+	p.bufferedTodoChan = make(chan *Todo)
 	p.errorsOccured = []error{}
 	p.wg = new(sync.WaitGroup)
+	p.lock = new(sync.Mutex)
+	p.fetchers = defaultFetchers(p.httpClient, p.downloadTimeout)
+	p.calendarsByURL = make(map[string][]*Calendar)
 	p.parsedCalendars = []*Calendar{}
 	p.parsedEvents = []*Event{}
+	p.parsedTodos = []*Todo{}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.barrier = make(chan struct{})
+	p.inputStopped = make(chan struct{})
+	p.outputStopped = make(chan struct{})
 
-	// buffers the events output chan
+	// buffers the events and todos output chans
 	go func() {
+		defer close(p.outputStopped)
+		// the events and todos that were not received are dropped
+		defer close(p.outputChan)
+		defer close(p.todoOutputChan)
 		for {
+			// sending to a nil chan blocks , so only the chans with something to send are selected
+			var output chan *Event
+			var event *Event
 			if len(p.parsedEvents) > 0 {
-				select {
-				case p.outputChan <- p.parsedEvents[0]:
-					p.parsedEvents = p.parsedEvents[1:]
-				case event := <-p.bufferedChan:
-					p.parsedEvents = append(p.parsedEvents, event)
-				}
-			} else {
-				event := <-p.bufferedChan
+				output = p.outputChan
+				event = p.parsedEvents[0]
+			}
+			var todoOutput chan *Todo
+			var todo *Todo
+			if len(p.parsedTodos) > 0 {
+				todoOutput = p.todoOutputChan
+				todo = p.parsedTodos[0]
+			}
+
+			// in streaming mode the parsing waits while the buffer is full
+			events, todos := p.bufferedChan, p.bufferedTodoChan
+			if p.streaming && len(p.parsedEvents) >= streamingBuffer {
+				events = nil
+			}
+			if p.streaming && len(p.parsedTodos) >= streamingBuffer {
+				todos = nil
+			}
+
+			select {
+			case output <- event:
+				p.parsedEvents = p.parsedEvents[1:]
+			case todoOutput <- todo:
+				p.parsedTodos = p.parsedTodos[1:]
+			case event := <-events:
 				p.parsedEvents = append(p.parsedEvents, event)
+			case todo := <-todos:
+				p.parsedTodos = append(p.parsedTodos, todo)
+			case <-p.ctx.Done():
+				return
 			}
 		}
 	}()
 
 	go func(input chan string) {
-		// endless loop for getting the ics urls
+		defer close(p.inputStopped)
+		// loop for getting the ics urls until the parser is stopped
 		for {
-			link := <-input
+			var link string
+			select {
+			case link = <-input:
+			case p.barrier <- struct{}{}:
+				continue
+			case <-p.ctx.Done():
+				return
+			}
 
 			// mark calendar in the wait group as not parsed
 			p.wg.Add(1)
 
 			// marks that we have statusCalendars +1 calendars to be parsed
-			mutex.Lock()
+			p.lock.Lock()
 			p.statusCalendars++
-			mutex.Unlock()
+			p.lock.Unlock()
 
 			go func(link string) {
 				// mark calendar in the wait group as  parsed
 				defer p.wg.Done()
 
-				iCalContent, err := p.getICal(link)
-				if err != nil {
-					p.errorsOccured = append(p.errorsOccured, err)
-
-					mutex.Lock()
-					// marks that we have parsed 1 calendar and we have statusCalendars -1 left to be parsed
-					p.statusCalendars--
-					mutex.Unlock()
-					return
-				}
-
-				// parse the ICal calendar
-				p.parseICalContent(iCalContent, link)
+				p.parseURL(link)
 
-				mutex.Lock()
+				p.lock.Lock()
 				// marks that we have parsed 1 calendar and we have statusCalendars -1 left to be parsed
 				p.statusCalendars--
-				mutex.Unlock()
+				p.lock.Unlock()
 
 			}(link)
 		}
 	}(p.inputChan)
-	// p.wg.Wait()
-	// return p.inputChan
 	return p
 }
 
@@ -110,149 +177,423 @@ func (p *Parser) Load(iCalContent string) {
 	p.parseICalContent(iCalContent, "")
 }
 
-//  returns the chan for calendar urls
+// LoadReader parses the calendars from r while reading it. Every event is sent to the
+// output chan as soon as its END:VEVENT is read , so the whole content is never held in memory.
+// The parsed events are still kept in their calendar , unless the parser is created with WithStreaming
+func (p *Parser) LoadReader(r io.Reader) error {
+	_, err := p.parseICalReader(r, "")
+	return err
+}
+
+//  returns the chan for calendar urls , nothing reads from it after the parser is closed
 func (p *Parser) GetInputChan() chan string {
 	return p.inputChan
 }
 
-// returns the chan where will be received events
+// returns the chan where will be received events , it is closed when the parser is closed
 func (p *Parser) GetOutputChan() chan *Event {
 	return p.outputChan
 }
 
+// returns the chan where will be received todos , it is closed when the parser is closed
+func (p *Parser) GetTodoOutputChan() chan *Todo {
+	return p.todoOutputChan
+}
+
 // returns the chan where will be received events
 func (p *Parser) GetCalendars() ([]*Calendar, error) {
 	if !p.Done() {
 		return nil, errors.New("Calendars not parsed")
 	}
-	return p.parsedCalendars, nil
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]*Calendar{}, p.parsedCalendars...), nil
 }
 
-// returns the array with the errors occurred while parsing the events
+// returns the array with the errors occurred while parsing the events , each of them is *ParseError
 func (p *Parser) GetErrors() ([]error, error) {
 	if !p.Done() {
 		return nil, errors.New("Calendars not parsed")
 	}
-	return p.errorsOccured, nil
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]error{}, p.errorsOccured...), nil
+}
+
+// returns the errors occurred while getting and parsing the calendar from the url
+func (p *Parser) GetErrorsForURL(url string) []*ParseError {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	errs := []*ParseError{}
+	for _, err := range p.errorsOccured {
+		if parseErr, ok := err.(*ParseError); ok && parseErr.GetURL() == url {
+			errs = append(errs, parseErr)
+		}
+	}
+	return errs
+}
+
+// records the error , also in the calendar when the error is about its content
+func (p *Parser) addError(cal *Calendar, err *ParseError) {
+	p.lock.Lock()
+	p.errorsOccured = append(p.errorsOccured, err)
+	p.lock.Unlock()
+	if cal != nil {
+		cal.addError(err)
+	}
 }
 
 // is everything is parsed
 func (p *Parser) Done() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	return p.statusCalendars == 0
 }
 
 // wait until everything is parsed
 func (p *Parser) Wait() {
+	// the urls that are already sent may not be counted yet , the input goroutine
+	// takes the barrier only after it has counted them
+	select {
+	case <-p.barrier:
+	case <-p.inputStopped:
+	}
 	p.wg.Wait()
 }
 
-//  get the data from the calendar
-func (p *Parser) getICal(url string) (string, error) {
-	re, _ := regexp.Compile(`http(s){0,1}:\/\/`)
+// stops the parser. The downloads and parsing in progress are cancelled , the goroutines
+// of the parser return and the output chan is closed. The calendars parsed so far are kept
+func (p *Parser) Close() error {
+	p.cancel()
+	<-p.inputStopped
+	p.wg.Wait()
+	<-p.outputStopped
+	return nil
+}
+
+// sends the event to the output chan , unless the parser is stopped
+func (p *Parser) sendEvent(event *Event) {
+	select {
+	case p.bufferedChan <- event:
+	case <-p.ctx.Done():
+	}
+}
+
+// sends the todo to the todo output chan , unless the parser is stopped
+func (p *Parser) sendTodo(todo *Todo) {
+	select {
+	case p.bufferedTodoChan <- todo:
+	case <-p.ctx.Done():
+	}
+}
 
-	var fileName string
-	var errDownload error
+// registers the fetcher for the urls with the scheme ( like "https" or "s3" ) , replacing the
+// one used so far. The urls without scheme are local files and use the "file" fetcher
+func (p *Parser) RegisterFetcher(scheme string, fetcher Fetcher) *Parser {
+	p.lock.Lock()
+	p.fetchers[strings.ToLower(scheme)] = fetcher
+	p.lock.Unlock()
+	return p
+}
 
-	if re.FindString(url) != "" {
-		// download the file and store it local
-		fileName, errDownload = downloadFromUrl(url)
+// returns the fetcher for the scheme of the url
+func (p *Parser) getFetcher(url string) (Fetcher, error) {
+	scheme := urlScheme(url)
+	p.lock.Lock()
+	fetcher, ok := p.fetchers[scheme]
+	p.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("There is no fetcher for %s", scheme)
+	}
+	return fetcher, nil
+}
 
-		if errDownload != nil {
-			return "", errDownload
-		}
+// sets the cache for the http , https and webcal urls. The calendars that are not modified
+// since they were parsed by the parser are reused without downloading and parsing them
+func (p *Parser) SetHTTPCache(cache HTTPCache) *Parser {
+	fetcher := NewHTTPFetcher(p.httpClient).SetTimeout(p.downloadTimeout).SetCache(cache)
+	for _, scheme := range []string{"http", "https", "webcal"} {
+		p.RegisterFetcher(scheme, fetcher)
+	}
+	return p
+}
 
-	} else { //  use a file from local storage
+// streams the inline attachments with more than limit bytes of base64 text to the writers returned
+// by open , instead of keeping them in memory. The attachment passed to open has its FMTTYPE and file name ,
+// its content is decoded to the writer that is closed after the last byte when it is an io.Closer
+func (p *Parser) SetAttachmentWriter(limit int, open func(a *Attachment) (io.Writer, error)) *Parser {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.attachmentLimit = limit
+	p.openAttachment = open
+	return p
+}
 
-		//  check if file exists
-		if fileExists(url) {
-			fileName = url
-		} else {
-			err := fmt.Sprintf("File %s does not exists", url)
-			return "", errors.New(err)
+// returns the cache hits and misses of the parser
+func (p *Parser) GetStats() Stats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.stats
+}
+
+//  get the data from the calendar , the returned reader must be closed
+func (p *Parser) getICal(ctx context.Context, url string) (io.ReadCloser, error) {
+	fetcher, err := p.getFetcher(url)
+	if err != nil {
+		return nil, err
+	}
+	return fetcher.Fetch(ctx, url)
+}
+
+// gets and parses the calendars from the url , reusing the calendars parsed before when they are not modified
+func (p *Parser) parseURL(url string) {
+	iCal, err := p.getICal(p.ctx, url)
+	if err == ErrNotModified {
+		p.lock.Lock()
+		calendars, ok := p.calendarsByURL[url]
+		if ok {
+			p.stats.cacheHits++
+			for _, cal := range calendars {
+				if !containsCalendar(p.parsedCalendars, cal) {
+					p.parsedCalendars = append(p.parsedCalendars, cal)
+				}
+			}
 		}
+		p.lock.Unlock()
+		if ok {
+			return
+		}
+		// nothing to reuse , the content is needed
+		iCal, err = p.getICal(context.WithValue(p.ctx, unconditionalKey{}, true), url)
+	}
+	if err != nil {
+		p.addError(nil, newParseError(url, 0, "", "", err))
+		return
 	}
 
-	//  read the file with the ical data
-	fileContent, errReadFile := ioutil.ReadFile(fileName)
+	// parse the ICal calendar
+	calendars, err := p.parseICalReader(iCal, url)
+	iCal.Close()
 
-	if errReadFile != nil {
-		return "", errReadFile
+	p.lock.Lock()
+	p.stats.cacheMisses++
+	if err == nil {
+		p.calendarsByURL[url] = calendars
+	} else {
+		// the calendars are not complete , they must not be reused
+		delete(p.calendarsByURL, url)
 	}
+	p.lock.Unlock()
 
-	if DeleteTempFiles && re.FindString(url) != "" {
-		os.Remove(fileName)
+	if err != nil {
+		p.addError(nil, newParseError(url, 0, "", "", err))
 	}
+}
 
-	return fmt.Sprintf("%s", fileContent), nil
+// checks if the calendar is in the slice
+func containsCalendar(calendars []*Calendar, cal *Calendar) bool {
+	for _, c := range calendars {
+		if c == cal {
+			return true
+		}
+	}
+	return false
 }
 
 // ======================== CALENDAR PARSING ===================
 
 // parses the iCal formated string to a calendar object
 func (p *Parser) parseICalContent(iCalContent, url string) {
-	ical := NewCalendar()
-	p.parsedCalendars = append(p.parsedCalendars, ical)
+	p.parseICalReader(strings.NewReader(iCalContent), url)
+}
+
+// parses the calendars in the stream , each event is sent to the output as soon as it is read.
+// returns the calendars from the stream
+func (p *Parser) parseICalReader(r io.Reader, url string) ([]*Calendar, error) {
+	d := newDecoder(r)
+	p.lock.Lock()
+	if p.openAttachment != nil {
+		open := p.openAttachment
+		d.lexer.stream = func(cl *contentLine) io.WriteCloser { return streamAttachment(cl, open) }
+		d.lexer.streamLimit = p.attachmentLimit
+	}
+	p.lock.Unlock()
+
+	// the calendar that is filled at the moment and the component with its properties
+	var ical *Calendar
+	var calInfo *component
+	var calInfoProps int
+	parsedCalendars := []*Calendar{}
+
+	// the repeating events are expanded when their calendar ends ,
+	// so that the instances replaced by other events are known
+	repeating := []*Event{}
+	endCalendar := func() {
+		if p.expandRecurrences {
+			for _, event := range repeating {
+				p.expandEvent(ical, event)
+			}
+		}
+		repeating = []*Event{}
+	}
+
+	startCalendar := func(info *component) {
+		ical = NewCalendar()
+		ical.SetUrl(url)
+		ical.windowsZones = p.windowsZones
+		p.lock.Lock()
+		p.parsedCalendars = append(p.parsedCalendars, ical)
+		p.lock.Unlock()
+		parsedCalendars = append(parsedCalendars, ical)
+		calInfo = info
+		calInfoProps = 0
+		p.updateICalInfo(ical, calInfo, &calInfoProps)
+	}
+
+	for {
+		if err := p.ctx.Err(); err != nil {
+			return parsedCalendars, err
+		}
+		comp, err := d.next()
+		for _, err := range d.takeErrors() {
+			if syntaxErr, ok := err.(*syntaxError); ok {
+				p.addError(ical, newParseError(url, syntaxErr.line, "", "", errors.New(syntaxErr.msg)))
+			} else {
+				p.addError(ical, newParseError(url, 0, "", "", err))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return parsedCalendars, err
+		}
+
+		if comp.name == "VCALENDAR" && d.calendar == nil {
+			// the calendar has ended , pick up the properties that came after its components
+			if ical == nil || calInfo != comp {
+				startCalendar(comp)
+			}
+			p.updateICalInfo(ical, calInfo, &calInfoProps)
+			endCalendar()
+			ical = nil
+			calInfo = nil
+			continue
+		}
+
+		if ical == nil || calInfo != d.calendar {
+			if ical != nil {
+				endCalendar()
+			}
+			startCalendar(d.calendar)
+		}
 
-	// split the data into calendar info and events data
-	eventsData, calInfo := explodeICal(iCalContent)
-	idCounter++
+		switch comp.name {
+		case "VTIMEZONE":
+			p.parseTimezone(ical, comp)
+		case "VTODO":
+			p.parseTodo(ical, comp)
+		case "VJOURNAL":
+			p.parseJournal(ical, comp)
+		case "VFREEBUSY":
+			p.parseFreeBusy(ical, comp)
+		case "VEVENT":
+			event := p.parseEvent(ical, comp)
+			if !p.streaming && (event.GetRRule() != "" || len(event.GetRDates()) > 0) {
+				repeating = append(repeating, event)
+			}
+		}
+	}
+
+	if ical != nil {
+		endCalendar()
+	}
+	// there were no components at all , still there is a calendar for this source
+	if len(parsedCalendars) == 0 {
+		startCalendar(nil)
+	}
+	return parsedCalendars, nil
+}
+
+// fills the calendar fields if new properties were read since the last call
+func (p *Parser) updateICalInfo(ical *Calendar, calInfo *component, parsedProps *int) {
+	if calInfo == nil || len(calInfo.properties) == *parsedProps {
+		return
+	}
+	*parsedProps = len(calInfo.properties)
+	p.parseICalInfo(ical, calInfo)
+}
 
-	// fill the calendar fields
+// fills the calendar fields
+func (p *Parser) parseICalInfo(ical *Calendar, calInfo *component) {
 	ical.SetName(p.parseICalName(calInfo))
 	ical.SetDesc(p.parseICalDesc(calInfo))
 	ical.SetVersion(p.parseICalVersion(calInfo))
-	ical.SetTimezone(p.parseICalTimezone(calInfo))
-	ical.SetUrl(url)
-
-	// parse the events and add them to ical
-	p.parseEvents(ical, eventsData)
-
+	ical.SetTimezone(p.parseICalTimezone(ical, calInfo))
+	ical.SetProdID(calInfo.value("PRODID"))
+	ical.SetMethod(strings.ToUpper(calInfo.value("METHOD")))
+	ical.SetCalScale(calInfo.value("CALSCALE"))
+	ical.SetRefreshInterval(p.parseICalRefreshInterval(ical, calInfo))
+	ical.SetColor(calInfo.value("COLOR"))
+	ical.SetImages(p.parseImages(calInfo))
+	ical.SetSource(calInfo.value("SOURCE"))
+	ical.SetProperties(rawProperties(calInfo))
 }
 
-// explodes the ICal content to array of events and calendar info
-func explodeICal(iCalContent string) ([]string, string) {
-	reEvents, _ := regexp.Compile(`(BEGIN:VEVENT(.*\n)*?END:VEVENT\r?\n)`)
-	allEvents := reEvents.FindAllString(iCalContent, len(iCalContent))
-	calInfo := reEvents.ReplaceAllString(iCalContent, "")
-	return allEvents, calInfo
+// parses the iCal Name , the RFC 7986 NAME or X-WR-CALNAME
+func (p *Parser) parseICalName(calInfo *component) string {
+	if prop := calInfo.property("NAME"); prop != nil {
+		return unescapeText(prop.value)
+	}
+	return unescapeText(calInfo.value("X-WR-CALNAME"))
 }
 
-// parses the iCal Name
-func (p *Parser) parseICalName(iCalContent string) string {
-	re, _ := regexp.Compile(`X-WR-CALNAME:.*?\n`)
-	result := re.FindString(iCalContent)
-	return trimField(result, "X-WR-CALNAME:")
+// parses the iCal description , the RFC 7986 DESCRIPTION or X-WR-CALDESC
+func (p *Parser) parseICalDesc(calInfo *component) string {
+	if prop := calInfo.property("DESCRIPTION"); prop != nil {
+		return unescapeText(prop.value)
+	}
+	return unescapeText(calInfo.value("X-WR-CALDESC"))
 }
 
-// parses the iCal description
-func (p *Parser) parseICalDesc(iCalContent string) string {
-	re, _ := regexp.Compile(`X-WR-CALDESC:.*?\n`)
-	result := re.FindString(iCalContent)
-	return trimField(result, "X-WR-CALDESC:")
+// parses how often the calendar should be polled , the REFRESH-INTERVAL or X-PUBLISHED-TTL
+func (p *Parser) parseICalRefreshInterval(ical *Calendar, calInfo *component) time.Duration {
+	for _, name := range []string{"REFRESH-INTERVAL", "X-PUBLISHED-TTL"} {
+		prop := calInfo.property(name)
+		if prop == nil {
+			continue
+		}
+		interval, err := parseDuration(prop.value)
+		if err != nil || interval <= 0 {
+			p.addError(ical, newParseError(ical.GetUrl(), prop.line, calInfo.name, name, fmt.Errorf("invalid value %q", prop.value)))
+			continue
+		}
+		return interval
+	}
+	return 0
 }
 
 // parses the iCal version
-func (p *Parser) parseICalVersion(iCalContent string) float64 {
-	re, _ := regexp.Compile(`VERSION:.*?\n`)
-	result := re.FindString(iCalContent)
+func (p *Parser) parseICalVersion(calInfo *component) float64 {
 	// parse the version result to float
-	ver, _ := strconv.ParseFloat(trimField(result, "VERSION:"), 64)
+	ver, _ := strconv.ParseFloat(calInfo.value("VERSION"), 64)
 	return ver
 }
 
 // parses the iCal timezone
-func (p *Parser) parseICalTimezone(iCalContent string) time.Location {
-	re, _ := regexp.Compile(`X-WR-TIMEZONE:.*?\n`)
-	result := re.FindString(iCalContent)
-
+func (p *Parser) parseICalTimezone(ical *Calendar, calInfo *component) time.Location {
 	// parse the timezone result to time.Location
-	timezone := trimField(result, "X-WR-TIMEZONE:")
+	timezone := calInfo.value("X-WR-TIMEZONE")
 	// create location instance
-	loc, err := time.LoadLocation(timezone)
+	loc, err := loadLocation(timezone, p.windowsZones)
 
 	// if fails with the timezone => go Local
 	if err != nil {
-		p.errorsOccured = append(p.errorsOccured, err)
+		line := 0
+		if prop := calInfo.property("X-WR-TIMEZONE"); prop != nil {
+			line = prop.line
+		}
+		p.addError(ical, newParseError(ical.GetUrl(), line, calInfo.name, "X-WR-TIMEZONE", err))
 		loc, _ = time.LoadLocation("UTC")
 	}
 	return *loc
@@ -260,289 +601,221 @@ func (p *Parser) parseICalTimezone(iCalContent string) time.Location {
 
 // ======================== EVENTS PARSING ===================
 
-// parses the iCal events Data
-func (p *Parser) parseEvents(cal *Calendar, eventsData []string) {
-	for _, eventData := range eventsData {
-		event := NewEvent()
-
-		start, startTZID := p.parseEventStart(eventData)
-		end, endTZID := p.parseEventEnd(eventData)
-		duration := p.parseEventDuration(eventData)
-
-		if end.Before(start) {
-			end = start.Add(duration)
-		}
-		// whole day event when both times are 00:00:00
-		wholeDay := start.Hour() == 0 && end.Hour() == 0 && start.Minute() == 0 && end.Minute() == 0 && start.Second() == 0 && end.Second() == 0
-
-		event.SetStartTZID(startTZID)
-		event.SetEndTZID(endTZID)
-		event.SetStatus(p.parseEventStatus(eventData))
-		event.SetSummary(p.parseEventSummary(eventData))
-		event.SetDescription(p.parseEventDescription(eventData))
-		event.SetImportedID(p.parseEventId(eventData))
-		event.SetClass(p.parseEventClass(eventData))
-		event.SetSequence(p.parseEventSequence(eventData))
-		event.SetCreated(p.parseEventCreated(eventData))
-		event.SetLastModified(p.parseEventModified(eventData))
-		event.SetRRule(p.parseEventRRule(eventData))
-		event.SetLocation(p.parseEventLocation(eventData))
-		event.SetGeo(p.parseEventGeo(eventData))
-		event.SetStart(start)
-		event.SetEnd(end)
-		event.SetWholeDayEvent(wholeDay)
-		event.SetAttendees(p.parseEventAttendees(eventData))
-		event.SetOrganizer(p.parseEventOrganizer(eventData))
-		event.SetCalendar(cal)
-		event.SetID(event.GenerateEventId())
-
-		cal.SetEvent(*event)
-		p.bufferedChan <- event
-
-		if RepeatRuleApply && event.GetRRule() != "" {
-
-			// until field
-			reUntil, _ := regexp.Compile(`UNTIL=(\d)*T(\d)*Z(;){0,1}`)
-			untilString := trimField(reUntil.FindString(event.GetRRule()), `(UNTIL=|;)`)
-			//  set until date
-			var until *time.Time
-			if untilString == "" {
-				until = nil
-			} else {
-				untilV, _ := time.Parse(IcsFormat, untilString)
-				until = &untilV
-			}
-
-			// INTERVAL field
-			reInterval, _ := regexp.Compile(`INTERVAL=(\d)*(;){0,1}`)
-			intervalString := trimField(reInterval.FindString(event.GetRRule()), `(INTERVAL=|;)`)
-			interval, _ := strconv.Atoi(intervalString)
-
-			if interval == 0 {
-				interval = 1
-			}
-
-			// count field
-			reCount, _ := regexp.Compile(`COUNT=(\d)*(;){0,1}`)
-			countString := trimField(reCount.FindString(event.GetRRule()), `(COUNT=|;)`)
-			count, _ := strconv.Atoi(countString)
-			if count == 0 {
-				count = MaxRepeats
-			}
-
-			// freq field
-			reFr, _ := regexp.Compile(`FREQ=[^;]*(;){0,1}`)
-			freq := trimField(reFr.FindString(event.GetRRule()), `(FREQ=|;)`)
-
-			// by month field
-			reBM, _ := regexp.Compile(`BYMONTH=[^;]*(;){0,1}`)
-			bymonth := trimField(reBM.FindString(event.GetRRule()), `(BYMONTH=|;)`)
-
-			// by day field
-			reBD, _ := regexp.Compile(`BYDAY=[^;]*(;){0,1}`)
-			byday := trimField(reBD.FindString(event.GetRRule()), `(BYDAY=|;)`)
-
-			// fmt.Printf("%#v \n", reBD.FindString(event.GetRRule()))
-			// fmt.Println("untilString", reUntil.FindString(event.GetRRule()))
-
-			//  set the freq modification of the dates
-			var years, days, months int
-			switch freq {
-			case "DAILY":
-				days = interval
-				months = 0
-				years = 0
-				break
-			case "WEEKLY":
-				days = 7
-				months = 0
-				years = 0
-				break
-			case "MONTHLY":
-				days = 0
-				months = interval
-				years = 0
-				break
-			case "YEARLY":
-				days = 0
-				months = 0
-				years = interval
-				break
-			}
+// parses a single iCal event and adds it to the calendar
+func (p *Parser) parseEvent(cal *Calendar, eventData *component) *Event {
+	event := NewEvent()
 
-			// number of current repeats
-			current := 0
-			// the current date in the main loop
-			freqDateStart := start
-			freqDateEnd := end
-
-			// loops by freq
-			for {
-				weekDaysStart := freqDateStart
-				weekDaysEnd := freqDateEnd
-
-				// check repeating by month
-				if bymonth == "" || strings.Contains(bymonth, weekDaysStart.Format("1")) {
-
-					if byday != "" {
-						// loops the weekdays
-						for i := 0; i < 7; i++ {
-							day := parseDayNameToIcsName(weekDaysStart.Format("Mon"))
-							if strings.Contains(byday, day) && weekDaysStart != start {
-								current++
-								count--
-								newE := *event
-								newE.SetStart(weekDaysStart)
-								newE.SetEnd(weekDaysEnd)
-								newE.SetID(newE.GenerateEventId())
-								newE.SetSequence(current)
-								if until == nil || (until != nil && until.Format(YmdHis) >= weekDaysStart.Format(YmdHis)) {
-									cal.SetEvent(newE)
-								}
-
-							}
-							weekDaysStart = weekDaysStart.AddDate(0, 0, 1)
-							weekDaysEnd = weekDaysEnd.AddDate(0, 0, 1)
-						}
-					} else {
-						//  we dont have loop by day so we put it on the same day
-						if weekDaysStart != start {
-							current++
-							count--
-							newE := *event
-							newE.SetStart(weekDaysStart)
-							newE.SetEnd(weekDaysEnd)
-							newE.SetID(newE.GenerateEventId())
-							newE.SetSequence(current)
-							if until == nil || (until != nil && until.Format(YmdHis) >= weekDaysStart.Format(YmdHis)) {
-								cal.SetEvent(newE)
-							}
-
-						}
-					}
+	start, startTZID := p.parseEventStart(cal, eventData)
+	end, endTZID := p.parseEventEnd(cal, eventData)
+	duration := p.parseEventDuration(eventData)
 
-				}
+	if end.Before(start) {
+		end = start.Add(duration)
+	}
+	// whole day event when the start is a DATE , midnight DATE-TIME values keep their times
+	wholeDay := isDateProperty(eventData.property("DTSTART"))
+
+	event.SetStartTZID(startTZID)
+	event.SetEndTZID(endTZID)
+	event.SetStatus(p.parseEventStatus(eventData))
+	event.SetSummary(p.parseEventSummary(eventData))
+	event.SetDescription(p.parseEventDescription(eventData))
+	event.SetImportedID(p.parseEventId(eventData))
+	event.SetClass(p.parseEventClass(eventData))
+	event.SetTransp(p.parseEventTransp(eventData))
+	event.SetSequence(p.parseEventSequence(eventData))
+	event.SetCreated(p.parseEventCreated(eventData))
+	event.SetLastModified(p.parseEventModified(eventData))
+	event.SetRRule(p.parseEventRRule(eventData))
+	event.SetExDates(p.parseEventExDates(cal, eventData))
+	event.SetRDates(p.parseEventRDates(cal, eventData))
+	event.SetRecurrenceID(p.parseEventRecurrenceID(cal, eventData))
+	event.SetLocation(p.parseEventLocation(eventData))
+	event.SetGeo(p.parseEventGeo(eventData))
+	event.SetColor(eventData.value("COLOR"))
+	event.SetImages(p.parseImages(eventData))
+	event.SetConferences(p.parseEventConferences(eventData))
+	event.SetSummaryTexts(p.parseTexts(eventData, "SUMMARY"))
+	event.SetDescriptionTexts(p.parseTexts(eventData, "DESCRIPTION"))
+	event.SetLocationTexts(p.parseTexts(eventData, "LOCATION"))
+	event.SetURL(eventData.value("URL"))
+	event.SetPriority(p.parsePriority(eventData))
+	event.SetCategories(p.parseCategories(eventData))
+	event.SetComments(p.parseTextValues(eventData, "COMMENT"))
+	event.SetContacts(p.parseTextValues(eventData, "CONTACT"))
+	event.SetResources(p.parseEventResources(eventData))
+	event.SetAttachments(p.parseAttachments(cal, eventData))
+	event.SetRelatedToList(p.parseRelatedTo(eventData))
+	event.SetDTStamp(p.parseEventDTStamp(eventData))
+	event.SetRequestStatuses(p.parseRequestStatuses(eventData))
+	event.SetStart(start)
+	event.SetEnd(end)
+	event.SetWholeDayEvent(wholeDay)
+	event.SetAttendees(p.parseEventAttendees(eventData))
+	event.SetOrganizer(p.parseEventOrganizer(eventData))
+	event.SetAlarms(p.parseEventAlarms(cal, eventData))
+	event.SetCalendar(cal)
+	event.SetID(event.GenerateEventId())
+
+	// in streaming mode the event is only sent to the output chan
+	if !p.streaming {
+		event.SetProperties(rawProperties(eventData))
+		cal.SetEvent(event)
+	}
+	p.sendEvent(event)
 
-				freqDateStart = freqDateStart.AddDate(years, months, days)
-				freqDateEnd = freqDateEnd.AddDate(years, months, days)
-				if current > MaxRepeats || count == 0 {
-					break
-				}
+	if event.GetRRule() != "" {
+		if _, err := event.GetRecurrenceRule(); err != nil {
+			p.addError(cal, newParseError(cal.GetUrl(), eventData.property("RRULE").line, eventData.name, "RRULE", err))
+		}
+	}
+	return event
+}
 
-				if until != nil && until.Format(YmdHis) <= freqDateStart.Format(YmdHis) {
-					break
-				}
-			}
+// adds to the calendar up to maxRepeats instances of the repeating event
+func (p *Parser) expandEvent(cal *Calendar, event *Event) {
+	occurrences := event.Occurrences()
 
+	for current := 1; current <= p.maxRepeats; {
+		newE, ok := occurrences.Next()
+		if !ok {
+			break
+		}
+		// the event itself and the instances that are replaced by other events are already in the calendar
+		if newE == event || !newE.GetRecurrenceID().IsZero() {
+			continue
 		}
+		newE.SetSequence(current)
+		cal.SetEvent(newE)
+		current++
 	}
 }
 
 // parses the event summary
-func (p *Parser) parseEventSummary(eventData string) string {
-	re, _ := regexp.Compile(`SUMMARY(?:;LANGUAGE=[a-zA-Z\-]+)?.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, `SUMMARY(?:;LANGUAGE=[a-zA-Z\-]+)?:`)
+func (p *Parser) parseEventSummary(eventData *component) string {
+	return unescapeText(eventData.value("SUMMARY"))
 }
 
 // parses the event status
-func (p *Parser) parseEventStatus(eventData string) string {
-	re, _ := regexp.Compile(`STATUS:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "STATUS:")
+func (p *Parser) parseEventStatus(eventData *component) string {
+	return eventData.value("STATUS")
 }
 
 // parses the event description
-func (p *Parser) parseEventDescription(eventData string) string {
-	re, _ := regexp.Compile(`DESCRIPTION:.*?\n(?:\s+.*?\n)*`)
-	result := re.FindString(eventData)
-	return trimField(strings.Replace(result, "\r\n ", "", -1), "DESCRIPTION:")
+func (p *Parser) parseEventDescription(eventData *component) string {
+	return unescapeText(eventData.value("DESCRIPTION"))
 }
 
 // parses the event id provided form google
-func (p *Parser) parseEventId(eventData string) string {
-	re, _ := regexp.Compile(`UID:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "UID:")
+func (p *Parser) parseEventId(eventData *component) string {
+	return eventData.value("UID")
 }
 
 // parses the event class
-func (p *Parser) parseEventClass(eventData string) string {
-	re, _ := regexp.Compile(`CLASS:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "CLASS:")
+func (p *Parser) parseEventClass(eventData *component) string {
+	return eventData.value("CLASS")
+}
+
+// parses the event TRANSP
+func (p *Parser) parseEventTransp(eventData *component) string {
+	return eventData.value("TRANSP")
 }
 
 // parses the event sequence
-func (p *Parser) parseEventSequence(eventData string) int {
-	re, _ := regexp.Compile(`SEQUENCE:.*?\n`)
-	result := re.FindString(eventData)
-	sq, _ := strconv.Atoi(trimField(result, "SEQUENCE:"))
+func (p *Parser) parseEventSequence(eventData *component) int {
+	sq, _ := strconv.Atoi(eventData.value("SEQUENCE"))
 	return sq
 }
 
 // parses the event created time
-func (p *Parser) parseEventCreated(eventData string) time.Time {
-	re, _ := regexp.Compile(`CREATED:.*?\n`)
-	result := re.FindString(eventData)
-	created := trimField(result, "CREATED:")
-	t, _ := time.Parse(IcsFormat, created)
+func (p *Parser) parseEventCreated(eventData *component) time.Time {
+	t, _ := time.Parse(IcsFormat, eventData.value("CREATED"))
 	return t
 }
 
 // parses the event modified time
-func (p *Parser) parseEventModified(eventData string) time.Time {
-	re, _ := regexp.Compile(`LAST-MODIFIED:.*?\n`)
-	result := re.FindString(eventData)
-	modified := trimField(result, "LAST-MODIFIED:")
-	t, _ := time.Parse(IcsFormat, modified)
+func (p *Parser) parseEventModified(eventData *component) time.Time {
+	t, _ := time.Parse(IcsFormat, eventData.value("LAST-MODIFIED"))
 	return t
 }
 
 // parses the event start time
-func (p *Parser) parseTimeField(fieldName string, eventData string) (time.Time, string) {
-	reWholeDay, _ := regexp.Compile(fmt.Sprintf(`%s;VALUE=DATE:.*?\n`, fieldName))
-	re, _ := regexp.Compile(fmt.Sprintf(`%s(;TZID=(.*?))?(;VALUE=DATE-TIME)?:(.*?)\n`, fieldName))
-	resultWholeDay := reWholeDay.FindString(eventData)
+func (p *Parser) parseTimeField(cal *Calendar, fieldName string, eventData *component) (time.Time, string) {
 	var t time.Time
 	var tzID string
 
-	if resultWholeDay != "" {
-		// whole day event
-		modified := trimField(resultWholeDay, fmt.Sprintf("%s;VALUE=DATE:", fieldName))
-		t, _ = time.Parse(IcsFormatWholeDay, modified)
-	} else {
+	prop := eventData.property(fieldName)
+	if prop == nil {
+		return t, tzID
+	}
+
+	if prop.param("VALUE") != "DATE" {
 		// event that has start hour and minute
-		result := re.FindStringSubmatch(eventData)
-		if result == nil || len(result) < 4 {
-			return t, tzID
-		}
-		tzID = result[2]
-		dt := result[4]
-		if !strings.Contains(dt, "Z") {
-			dt = fmt.Sprintf("%sZ", dt)
-		}
-		t, _ = time.Parse(IcsFormat, dt)
+		tzID = prop.param("TZID")
+	}
+	t, err := p.parseTimeValue(cal, eventData, prop, prop.value)
+	if err != nil {
+		p.addError(cal, newParseError(cal.GetUrl(), prop.line, eventData.name, fieldName, fmt.Errorf("invalid value %q", prop.value)))
 	}
 
 	return t, tzID
 }
 
+// reports if the property has a DATE value , by its VALUE parameter or by the length of the value
+func isDateProperty(prop *contentLine) bool {
+	return prop != nil && (prop.param("VALUE") == "DATE" || len(prop.value) == len(IcsFormatWholeDay))
+}
+
+// parses a DATE or DATE-TIME value of the property. UTC values end with Z , the others
+// are in the time zone of their TZID. Dates and times without TZID are in the time zone of the calendar
+func (p *Parser) parseTimeValue(cal *Calendar, eventData *component, prop *contentLine, value string) (time.Time, error) {
+	if prop.param("VALUE") == "DATE" || len(value) == len(IcsFormatWholeDay) {
+		// whole day
+		return time.ParseInLocation(IcsFormatWholeDay, value, cal.location())
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(IcsFormat, value)
+	}
+
+	loc := cal.location()
+	if tzID := prop.param("TZID"); tzID != "" {
+		loc = p.timezoneFor(cal, eventData, prop)
+	}
+	return time.ParseInLocation(icsFormatLocal, value, loc)
+}
+
+// parses the values of all the properties with the given name , each of them may have a comma separated list.
+// PERIOD values are reduced to their start
+func (p *Parser) parseTimeList(cal *Calendar, fieldName string, eventData *component) []time.Time {
+	times := []time.Time{}
+	for _, prop := range eventData.propertiesByName(fieldName) {
+		for _, value := range strings.Split(prop.value, ",") {
+			if i := strings.IndexByte(value, '/'); i >= 0 {
+				value = value[:i]
+			}
+			t, err := p.parseTimeValue(cal, eventData, prop, strings.TrimSpace(value))
+			if err != nil {
+				p.addError(cal, newParseError(cal.GetUrl(), prop.line, eventData.name, fieldName, fmt.Errorf("invalid value %q", value)))
+				continue
+			}
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
 // parses the event start time
-func (p *Parser) parseEventStart(eventData string) (time.Time, string) {
-	return p.parseTimeField("DTSTART", eventData)
+func (p *Parser) parseEventStart(cal *Calendar, eventData *component) (time.Time, string) {
+	return p.parseTimeField(cal, "DTSTART", eventData)
 }
 
 // parses the event end time
-func (p *Parser) parseEventEnd(eventData string) (time.Time, string) {
-	return p.parseTimeField("DTEND", eventData)
+func (p *Parser) parseEventEnd(cal *Calendar, eventData *component) (time.Time, string) {
+	return p.parseTimeField(cal, "DTEND", eventData)
 }
 
-func (p *Parser) parseEventDuration(eventData string) time.Duration {
-	reDuration, _ := regexp.Compile(`DURATION:.*?\n`)
-	result := reDuration.FindString(eventData)
-	trimmed := trimField(result, "DURATION:")
-	parsedDuration, err := duration.FromString(trimmed)
+func (p *Parser) parseEventDuration(eventData *component) time.Duration {
+	parsedDuration, err := duration.FromString(eventData.value("DURATION"))
 	var output time.Duration
 
 	if err == nil {
@@ -552,27 +825,35 @@ func (p *Parser) parseEventDuration(eventData string) time.Duration {
 	return output
 }
 
+// parses the event EXDATE , the excluded instances
+func (p *Parser) parseEventExDates(cal *Calendar, eventData *component) []time.Time {
+	return p.parseTimeList(cal, "EXDATE", eventData)
+}
+
+// parses the event RDATE , the additional instances
+func (p *Parser) parseEventRDates(cal *Calendar, eventData *component) []time.Time {
+	return p.parseTimeList(cal, "RDATE", eventData)
+}
+
+// parses the event RECURRENCE-ID , the instance of the repeating event that this event replaces
+func (p *Parser) parseEventRecurrenceID(cal *Calendar, eventData *component) time.Time {
+	t, _ := p.parseTimeField(cal, "RECURRENCE-ID", eventData)
+	return t
+}
+
 // parses the event RRULE (the repeater)
-func (p *Parser) parseEventRRule(eventData string) string {
-	re, _ := regexp.Compile(`RRULE:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "RRULE:")
+func (p *Parser) parseEventRRule(eventData *component) string {
+	return eventData.value("RRULE")
 }
 
 // parses the event LOCATION
-func (p *Parser) parseEventLocation(eventData string) string {
-	re, _ := regexp.Compile(`LOCATION:.*?\n`)
-	result := re.FindString(eventData)
-	return trimField(result, "LOCATION:")
+func (p *Parser) parseEventLocation(eventData *component) string {
+	return unescapeText(eventData.value("LOCATION"))
 }
 
 // parses the event GEO
-func (p *Parser) parseEventGeo(eventData string) *Geo {
-	re, _ := regexp.Compile(`GEO:.*?\n`)
-	result := re.FindString(eventData)
-
-	value := trimField(result, "GEO:")
-	values := strings.Split(value, ";")
+func (p *Parser) parseEventGeo(eventData *component) *Geo {
+	values := strings.Split(eventData.value("GEO"), ";")
 	if len(values) < 2 {
 		return nil
 	}
@@ -580,19 +861,398 @@ func (p *Parser) parseEventGeo(eventData string) *Geo {
 	return NewGeo(values[0], values[1])
 }
 
+// parses the event CONFERENCE links , when there are none the meeting links are searched in the LOCATION and the DESCRIPTION
+func (p *Parser) parseEventConferences(eventData *component) []*Conference {
+	conferences := []*Conference{}
+	for _, prop := range eventData.propertiesByName("CONFERENCE") {
+		conference := NewConference(prop.value)
+		for _, features := range prop.paramValues("FEATURE") {
+			for _, feature := range strings.Split(features, ",") {
+				conference.SetFeature(strings.ToUpper(strings.TrimSpace(feature)))
+			}
+		}
+		conference.SetLabel(prop.param("LABEL"))
+		conferences = append(conferences, conference)
+	}
+	if len(conferences) > 0 {
+		return conferences
+	}
+	return detectConferences(p.parseEventLocation(eventData), p.parseEventDescription(eventData))
+}
+
+// parses the event RESOURCES , each property may have a list of them
+func (p *Parser) parseEventResources(eventData *component) []string {
+	resources := []string{}
+	for _, prop := range eventData.propertiesByName("RESOURCES") {
+		resources = append(resources, splitTextList(prop.value)...)
+	}
+	return resources
+}
+
+// parses the event DTSTAMP
+func (p *Parser) parseEventDTStamp(eventData *component) time.Time {
+	t, _ := time.Parse(IcsFormat, eventData.value("DTSTAMP"))
+	return t
+}
+
+// parses the unescaped values of all the TEXT properties with the given name
+func (p *Parser) parseTextValues(compData *component, name string) []string {
+	values := []string{}
+	for _, prop := range compData.propertiesByName(name) {
+		values = append(values, unescapeText(prop.value))
+	}
+	return values
+}
+
+// parses all the properties with the given name with their LANGUAGE and ALTREP
+func (p *Parser) parseTexts(compData *component, name string) []*Text {
+	texts := []*Text{}
+	for _, prop := range compData.propertiesByName(name) {
+		texts = append(texts, NewText(unescapeText(prop.value)).SetLanguage(prop.param("LANGUAGE")).SetAltRep(prop.param("ALTREP")))
+	}
+	return texts
+}
+
+// parses the REQUEST-STATUS properties , code;description[;data]
+func (p *Parser) parseRequestStatuses(compData *component) []*RequestStatus {
+	statuses := []*RequestStatus{}
+	for _, prop := range compData.propertiesByName("REQUEST-STATUS") {
+		parts := splitText(prop.value, ';')
+		rs := NewRequestStatus(parts[0], "")
+		if len(parts) > 1 {
+			rs.description = parts[1]
+		}
+		if len(parts) > 2 {
+			rs.SetData(strings.Join(parts[2:], ";"))
+		}
+		statuses = append(statuses, rs)
+	}
+	return statuses
+}
+
+// ======================== TODOS PARSING ===================
+
+// parses a single iCal todo and adds it to the calendar
+func (p *Parser) parseTodo(cal *Calendar, todoData *component) *Todo {
+	todo := NewTodo()
+
+	start, startTZID := p.parseTimeField(cal, "DTSTART", todoData)
+	due, dueTZID := p.parseTimeField(cal, "DUE", todoData)
+	if due.IsZero() && !start.IsZero() {
+		if duration := p.parseEventDuration(todoData); duration > 0 {
+			due = start.Add(duration)
+		}
+	}
+	// whole day todo when its start , or its due without start , is a DATE
+	timeProp := todoData.property("DTSTART")
+	if timeProp == nil {
+		timeProp = todoData.property("DUE")
+	}
+	wholeDay := isDateProperty(timeProp)
+
+	todo.SetStart(start)
+	todo.SetStartTZID(startTZID)
+	todo.SetDue(due)
+	todo.SetDueTZID(dueTZID)
+	todo.SetWholeDay(wholeDay)
+	todo.SetCompleted(p.parseTodoCompleted(todoData))
+	todo.SetStatus(p.parseEventStatus(todoData))
+	todo.SetSummary(p.parseEventSummary(todoData))
+	todo.SetDescription(p.parseEventDescription(todoData))
+	todo.SetLocation(p.parseEventLocation(todoData))
+	todo.SetImportedID(p.parseEventId(todoData))
+	todo.SetClass(p.parseEventClass(todoData))
+	todo.SetSequence(p.parseEventSequence(todoData))
+	todo.SetCreated(p.parseEventCreated(todoData))
+	todo.SetLastModified(p.parseEventModified(todoData))
+	todo.SetPriority(p.parsePriority(todoData))
+	todo.SetPercentComplete(p.parseTodoPercentComplete(todoData))
+	todo.SetRelatedToList(p.parseRelatedTo(todoData))
+	todo.SetAttendees(p.parseEventAttendees(todoData))
+	todo.SetOrganizer(p.parseEventOrganizer(todoData))
+	todo.SetID(todo.GenerateTodoId())
+
+	// in streaming mode the todo is only sent to the todo output chan
+	if !p.streaming {
+		todo.SetProperties(rawProperties(todoData))
+		cal.SetTodo(todo)
+	}
+	p.sendTodo(todo)
+	return todo
+}
+
+// parses the todo completed time
+func (p *Parser) parseTodoCompleted(todoData *component) time.Time {
+	t, _ := time.Parse(IcsFormat, todoData.value("COMPLETED"))
+	return t
+}
+
+// parses the PRIORITY of the component
+func (p *Parser) parsePriority(compData *component) int {
+	priority, _ := strconv.Atoi(compData.value("PRIORITY"))
+	return priority
+}
+
+// parses the todo percent complete
+func (p *Parser) parseTodoPercentComplete(todoData *component) int {
+	percent, _ := strconv.Atoi(todoData.value("PERCENT-COMPLETE"))
+	return percent
+}
+
+// parses the UIDs of the related components in RELATED-TO
+func (p *Parser) parseRelatedTo(compData *component) []string {
+	uids := []string{}
+	for _, prop := range compData.propertiesByName("RELATED-TO") {
+		uids = append(uids, prop.value)
+	}
+	return uids
+}
+
+// ======================== JOURNALS PARSING ===================
+
+// parses a single iCal journal and adds it to the calendar
+func (p *Parser) parseJournal(cal *Calendar, journalData *component) *Journal {
+	journal := NewJournal()
+
+	start, startTZID := p.parseTimeField(cal, "DTSTART", journalData)
+	journal.SetStart(start)
+	journal.SetStartTZID(startTZID)
+	journal.SetWholeDay(isDateProperty(journalData.property("DTSTART")))
+	journal.SetSummary(p.parseEventSummary(journalData))
+	journal.SetDescriptions(p.parseJournalDescriptions(journalData))
+	journal.SetAttachments(p.parseAttachments(cal, journalData))
+	journal.SetCategories(p.parseCategories(journalData))
+	journal.SetStatus(p.parseEventStatus(journalData))
+	journal.SetImportedID(p.parseEventId(journalData))
+	journal.SetClass(p.parseEventClass(journalData))
+	journal.SetSequence(p.parseEventSequence(journalData))
+	journal.SetCreated(p.parseEventCreated(journalData))
+	journal.SetLastModified(p.parseEventModified(journalData))
+	journal.SetOrganizer(p.parseEventOrganizer(journalData))
+	journal.SetProperties(rawProperties(journalData))
+	journal.SetID(journal.GenerateJournalId())
+
+	cal.SetJournal(journal)
+	return journal
+}
+
+// parses all the journal descriptions
+func (p *Parser) parseJournalDescriptions(journalData *component) []string {
+	descriptions := []string{}
+	for _, prop := range journalData.propertiesByName("DESCRIPTION") {
+		descriptions = append(descriptions, unescapeText(prop.value))
+	}
+	return descriptions
+}
+
+// parses the ATTACH properties , links or inline base64 content
+func (p *Parser) parseAttachments(cal *Calendar, compData *component) []*Attachment {
+	attachments := []*Attachment{}
+	for _, prop := range compData.propertiesByName("ATTACH") {
+		if streamed, ok := prop.streamed.(*base64Writer); ok {
+			if streamed.err != nil {
+				p.addError(cal, newParseError(cal.GetUrl(), prop.line, compData.name, "ATTACH", streamed.err))
+			}
+			attachments = append(attachments, streamed.attachment)
+			continue
+		}
+		attachments = append(attachments, newAttachment(prop))
+	}
+	return attachments
+}
+
+// creates the attachment of the ATTACH property with its FMTTYPE and file name
+func newAttachment(prop *contentLine) *Attachment {
+	var attachment *Attachment
+	if isInline(prop) {
+		attachment = &Attachment{data: prop.value}
+	} else {
+		attachment = NewAttachmentURI(prop.value)
+	}
+	attachment.SetFmtType(prop.param("FMTTYPE"))
+	// X-FILENAME is used by the most producers , FILENAME by some
+	filename := prop.param("X-FILENAME")
+	if filename == "" {
+		filename = prop.param("FILENAME")
+	}
+	return attachment.SetFilename(filename)
+}
+
+// reports if the value of the property is base64 content
+func isInline(prop *contentLine) bool {
+	return strings.EqualFold(prop.param("VALUE"), "BINARY") || strings.EqualFold(prop.param("ENCODING"), "BASE64")
+}
+
+// returns the writer that decodes the value of the inline ATTACH to the writer from open ,
+// nil for the other properties. When open fails the content is dropped and the error reported
+func streamAttachment(cl *contentLine, open func(a *Attachment) (io.Writer, error)) io.WriteCloser {
+	if cl.name != "ATTACH" || !isInline(cl) {
+		return nil
+	}
+	attachment := newAttachment(cl)
+	attachment.data = ""
+	attachment.streamed = true
+	w, err := open(attachment)
+	if err != nil {
+		return &base64Writer{dst: io.Discard, err: err, attachment: attachment}
+	}
+	return &base64Writer{dst: w, attachment: attachment}
+}
+
+// parses the RFC 7986 IMAGE properties , links or inline pictures
+func (p *Parser) parseImages(compData *component) []*Image {
+	images := []*Image{}
+	for _, prop := range compData.propertiesByName("IMAGE") {
+		var image *Image
+		if isInline(prop) {
+			image = &Image{data: prop.value}
+		} else {
+			image = NewImageURI(prop.value)
+		}
+		image.SetFmtType(prop.param("FMTTYPE"))
+		image.SetDisplay(prop.param("DISPLAY"))
+		images = append(images, image)
+	}
+	return images
+}
+
+// parses the categories of all the CATEGORIES properties
+func (p *Parser) parseCategories(compData *component) []string {
+	categories := []string{}
+	for _, prop := range compData.propertiesByName("CATEGORIES") {
+		categories = append(categories, splitTextList(prop.value)...)
+	}
+	return categories
+}
+
+// ======================== FREE/BUSY PARSING ===================
+
+// parses the VFREEBUSY and adds it to the calendar
+func (p *Parser) parseFreeBusy(cal *Calendar, fbData *component) *FreeBusy {
+	fb := NewFreeBusy()
+	start, _ := p.parseTimeField(cal, "DTSTART", fbData)
+	end, _ := p.parseTimeField(cal, "DTEND", fbData)
+	fb.SetStart(start)
+	fb.SetEnd(end)
+	fb.SetImportedID(fbData.value("UID"))
+	fb.SetOrganizer(p.parseEventOrganizer(fbData))
+	fb.SetAttendees(p.parseEventAttendees(fbData))
+	fb.SetPeriods(p.parseFreeBusyPeriods(cal, fbData))
+	fb.SetProperties(rawProperties(fbData))
+	cal.SetFreeBusy(fb)
+	return fb
+}
+
+// parses the FREEBUSY periods , each of them is start/end or start/duration
+func (p *Parser) parseFreeBusyPeriods(cal *Calendar, fbData *component) []*Period {
+	periods := []*Period{}
+	for _, prop := range fbData.propertiesByName("FREEBUSY") {
+		fbType := strings.ToUpper(prop.param("FBTYPE"))
+		if fbType == "" {
+			fbType = "BUSY"
+		}
+		for _, value := range strings.Split(prop.value, ",") {
+			period, err := p.parsePeriod(cal, fbData, prop, strings.TrimSpace(value), fbType)
+			if err != nil {
+				p.addError(cal, newParseError(cal.GetUrl(), prop.line, fbData.name, "FREEBUSY", fmt.Errorf("invalid value %q", value)))
+				continue
+			}
+			periods = append(periods, period)
+		}
+	}
+	return periods
+}
+
+// parses PERIOD value
+func (p *Parser) parsePeriod(cal *Calendar, compData *component, prop *contentLine, value, fbType string) (*Period, error) {
+	i := strings.IndexByte(value, '/')
+	if i < 0 {
+		return nil, errors.New("missing end of the period")
+	}
+	start, err := p.parseTimeValue(cal, compData, prop, value[:i])
+	if err != nil {
+		return nil, err
+	}
+	var end time.Time
+	if strings.HasPrefix(value[i+1:], "P") || strings.HasPrefix(value[i+1:], "+P") {
+		d, err := parseDuration(value[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		end = start.Add(d)
+	} else if end, err = p.parseTimeValue(cal, compData, prop, value[i+1:]); err != nil {
+		return nil, err
+	}
+	return NewPeriod(start, end, fbType), nil
+}
+
+// ======================== ALARMS PARSING ===================
+
+// parses the VALARMs of the event , the ones without valid TRIGGER are skipped
+func (p *Parser) parseEventAlarms(cal *Calendar, eventData *component) []*Alarm {
+	alarms := []*Alarm{}
+	for _, alarmData := range eventData.componentsByName("VALARM") {
+		if alarm := p.parseAlarm(cal, alarmData); alarm != nil {
+			alarms = append(alarms, alarm)
+		}
+	}
+	return alarms
+}
+
+// parses single VALARM
+func (p *Parser) parseAlarm(cal *Calendar, alarmData *component) *Alarm {
+	alarm := NewAlarm()
+	alarm.SetAction(alarmData.value("ACTION"))
+	alarm.SetSummary(unescapeText(alarmData.value("SUMMARY")))
+	alarm.SetDescription(unescapeText(alarmData.value("DESCRIPTION")))
+	alarm.SetAttendees(p.parseEventAttendees(alarmData))
+	alarm.SetProperties(rawProperties(alarmData))
+
+	trigger := alarmData.property("TRIGGER")
+	if trigger == nil {
+		p.addError(cal, newParseError(cal.GetUrl(), alarmData.line, alarmData.name, "TRIGGER", errors.New("missing trigger")))
+		return nil
+	}
+	if trigger.param("VALUE") == "DATE-TIME" {
+		t, err := time.Parse(IcsFormat, trigger.value)
+		if err != nil {
+			p.addError(cal, newParseError(cal.GetUrl(), trigger.line, alarmData.name, "TRIGGER", fmt.Errorf("invalid value %q", trigger.value)))
+			return nil
+		}
+		alarm.SetTriggerTime(t)
+	} else {
+		d, err := parseDuration(trigger.value)
+		if err != nil {
+			p.addError(cal, newParseError(cal.GetUrl(), trigger.line, alarmData.name, "TRIGGER", fmt.Errorf("invalid value %q", trigger.value)))
+			return nil
+		}
+		alarm.SetTrigger(d)
+		if trigger.param("RELATED") == "END" {
+			alarm.SetTriggerRelated("END")
+		}
+	}
+
+	// DURATION and REPEAT are meaningful only together
+	if prop := alarmData.property("DURATION"); prop != nil {
+		d, err := parseDuration(prop.value)
+		if err != nil {
+			p.addError(cal, newParseError(cal.GetUrl(), prop.line, alarmData.name, "DURATION", fmt.Errorf("invalid value %q", prop.value)))
+		}
+		alarm.SetDuration(d)
+	}
+	repeat, _ := strconv.Atoi(alarmData.value("REPEAT"))
+	alarm.SetRepeat(repeat)
+	return alarm
+}
+
 // ======================== ATTENDEE PARSING ===================
 
 // parses the event attendees
-func (p *Parser) parseEventAttendees(eventData string) []*Attendee {
+func (p *Parser) parseEventAttendees(eventData *component) []*Attendee {
 	attendeesObj := []*Attendee{}
-	re, _ := regexp.Compile(`ATTENDEE(:|;)(.*?\r?\n)(\s.*?\r?\n)*`)
-	attendees := re.FindAllString(eventData, len(eventData))
 
-	for _, attendeeData := range attendees {
-		if attendeeData == "" {
-			continue
-		}
-		attendee := p.parseAttendee(strings.Replace(strings.Replace(attendeeData, "\r", "", 1), "\n ", "", 1))
+	for _, attendeeData := range eventData.propertiesByName("ATTENDEE") {
+		attendee := p.parseAttendee(attendeeData)
 		//  check for any fields set
 		if attendee.GetEmail() != "" || attendee.GetName() != "" || attendee.GetRole() != "" || attendee.GetStatus() != "" || attendee.GetType() != "" {
 			attendeesObj = append(attendeesObj, attendee)
@@ -602,24 +1262,17 @@ func (p *Parser) parseEventAttendees(eventData string) []*Attendee {
 }
 
 // parses the event organizer
-func (p *Parser) parseEventOrganizer(eventData string) *Attendee {
-
-	re, _ := regexp.Compile(`ORGANIZER(:|;)(.*?\r?\n)(\s.*?\r?\n)*`)
-	organizerData := re.FindString(eventData)
-	if organizerData == "" {
+func (p *Parser) parseEventOrganizer(eventData *component) *Attendee {
+	organizerData := eventData.property("ORGANIZER")
+	if organizerData == nil {
 		return nil
 	}
-	organizerDataFormated := strings.Replace(strings.Replace(organizerData, "\r", "", 1), "\n ", "", 1)
 
-	a := NewAttendee()
-	a.SetEmail(p.parseAttendeeMail(organizerDataFormated))
-	a.SetName(p.parseOrganizerName(organizerDataFormated))
-
-	return a
+	return p.parseAttendee(organizerData)
 }
 
 //  parse attendee properties
-func (p *Parser) parseAttendee(attendeeData string) *Attendee {
+func (p *Parser) parseAttendee(attendeeData *contentLine) *Attendee {
 
 	a := NewAttendee()
 	a.SetEmail(p.parseAttendeeMail(attendeeData))
@@ -627,63 +1280,57 @@ func (p *Parser) parseAttendee(attendeeData string) *Attendee {
 	a.SetRole(p.parseAttendeeRole(attendeeData))
 	a.SetStatus(p.parseAttendeeStatus(attendeeData))
 	a.SetType(p.parseAttendeeType(attendeeData))
+	a.SetRSVP(strings.EqualFold(attendeeData.param("RSVP"), "TRUE"))
+	a.SetDelegatedTo(p.parseCalAddresses(attendeeData.paramValues("DELEGATED-TO")))
+	a.SetDelegatedFrom(p.parseCalAddresses(attendeeData.paramValues("DELEGATED-FROM")))
+	a.SetMember(p.parseCalAddresses(attendeeData.paramValues("MEMBER")))
+	a.SetSentBy(trimMailto(attendeeData.param("SENT-BY")))
+	a.SetDir(attendeeData.param("DIR"))
+	a.SetLanguage(attendeeData.param("LANGUAGE"))
 	return a
 }
 
 // parses the attendee email
-func (p *Parser) parseAttendeeMail(attendeeData string) string {
-	re, _ := regexp.Compile(`mailto:.*?\n`)
-	result := re.FindString(attendeeData)
-	return trimField(result, "mailto:")
+func (p *Parser) parseAttendeeMail(attendeeData *contentLine) string {
+	return trimMailto(attendeeData.value)
 }
 
-// parses the attendee status
-func (p *Parser) parseAttendeeStatus(attendeeData string) string {
-	re, _ := regexp.Compile(`PARTSTAT=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
+// parses the calendar addresses of a parameter to emails , nil when there are none
+func (p *Parser) parseCalAddresses(values []string) []string {
+	if len(values) == 0 {
+		return nil
 	}
-	return trimField(result, `(PARTSTAT=|;)`)
+	emails := []string{}
+	for _, value := range values {
+		emails = append(emails, trimMailto(value))
+	}
+	return emails
 }
 
-// parses the attendee role
-func (p *Parser) parseAttendeeRole(attendeeData string) string {
-	re, _ := regexp.Compile(`ROLE=.*?;`)
-	result := re.FindString(attendeeData)
-
-	if result == "" {
-		return ""
+// removes the mailto: from the calendar address
+func trimMailto(value string) string {
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		return value[7:]
 	}
-	return trimField(result, `(ROLE=|;)`)
+	return value
 }
 
-// parses the attendee Name
-func (p *Parser) parseAttendeeName(attendeeData string) string {
-	re, _ := regexp.Compile(`CN=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(CN=|;)`)
+// parses the attendee status
+func (p *Parser) parseAttendeeStatus(attendeeData *contentLine) PartStat {
+	return PartStat(strings.ToUpper(attendeeData.param("PARTSTAT")))
 }
 
-// parses the organizer Name
-func (p *Parser) parseOrganizerName(orgData string) string {
-	re, _ := regexp.Compile(`CN=.*?:`)
-	result := re.FindString(orgData)
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(CN=|:)`)
+// parses the attendee role
+func (p *Parser) parseAttendeeRole(attendeeData *contentLine) Role {
+	return Role(strings.ToUpper(attendeeData.param("ROLE")))
+}
+
+// parses the attendee Name
+func (p *Parser) parseAttendeeName(attendeeData *contentLine) string {
+	return attendeeData.param("CN")
 }
 
 // parses the attendee type
-func (p *Parser) parseAttendeeType(attendeeData string) string {
-	re, _ := regexp.Compile(`CUTYPE=.*?;`)
-	result := re.FindString(attendeeData)
-	if result == "" {
-		return ""
-	}
-	return trimField(result, `(CUTYPE=|;)`)
+func (p *Parser) parseAttendeeType(attendeeData *contentLine) CUType {
+	return CUType(strings.ToUpper(attendeeData.param("CUTYPE")))
 }
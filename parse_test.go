@@ -1,9 +1,16 @@
 package ics
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -35,6 +42,97 @@ func TestLoadCalendar(t *testing.T) {
 	}
 }
 
+func TestLoadReaderStreamsEvents(t *testing.T) {
+	parser := New()
+	reader, writer := io.Pipe()
+
+	loaded := make(chan error)
+	go func() {
+		loaded <- parser.LoadReader(reader)
+	}()
+
+	writer.Write([]byte("BEGIN:VCALENDAR\r\nX-WR-CALNAME:First\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20140616T060000Z\r\nEND:VEVENT\r\n"))
+
+	// the event must be received before the rest of the stream is written
+	select {
+	case event := <-parser.GetOutputChan():
+		if event.GetImportedID() != "1" {
+			t.Errorf("Expected event with UID 1, got %s", event.GetImportedID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The event was not sent before the end of the stream")
+	}
+
+	writer.Write([]byte("END:VCALENDAR\r\nBEGIN:VCALENDAR\r\nX-WR-CALNAME:Second\r\nBEGIN:VEVENT\r\nUID:2\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	writer.Close()
+
+	if err := <-loaded; err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	calendars, err := parser.GetCalendars()
+	if err != nil {
+		t.Fatalf("Failed to get calendars ( %s )", err)
+	}
+	if len(calendars) != 2 {
+		t.Fatalf("Expected 2 calendars, found %d calendars", len(calendars))
+	}
+	if calendars[0].GetName() != "First" || calendars[1].GetName() != "Second" {
+		t.Errorf("Unexpected calendar names %s and %s", calendars[0].GetName(), calendars[1].GetName())
+	}
+	if len(calendars[1].GetEvents()) != 1 {
+		t.Errorf("Expected 1 event in the second calendar, got %d", len(calendars[1].GetEvents()))
+	}
+}
+
+func TestLoadReaderStreamingMode(t *testing.T) {
+	parser := New(WithStreaming(true))
+	reader, writer := io.Pipe()
+
+	loaded := make(chan error)
+	go func() {
+		loaded <- parser.LoadReader(reader)
+	}()
+
+	written := make(chan int, 200)
+	go func() {
+		writer.Write([]byte("BEGIN:VCALENDAR\r\n"))
+		for i := 0; i < 200; i++ {
+			writer.Write([]byte(fmt.Sprintf("BEGIN:VEVENT\r\nUID:%d\r\nDTSTART:20140616T060000Z\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\n", i)))
+			written <- i
+		}
+		writer.Write([]byte("END:VCALENDAR\r\n"))
+		writer.Close()
+	}()
+
+	// nobody reads the output chan , so the parsing waits for the consumer
+	time.Sleep(100 * time.Millisecond)
+	if len(written) == 200 {
+		t.Errorf("Expected the parsing to wait for the consumer , all the events were read")
+	}
+
+	received := 0
+	for received < 200 {
+		select {
+		case event := <-parser.GetOutputChan():
+			if event.GetImportedID() != fmt.Sprint(received) {
+				t.Errorf("Expected event with UID %d, got %s", received, event.GetImportedID())
+			}
+			received++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 200 events, received %d", received)
+		}
+	}
+	if err := <-loaded; err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	calendars, _ := parser.GetCalendars()
+	if len(calendars) != 1 || len(calendars[0].GetEvents()) != 0 {
+		t.Errorf("Expected 1 calendar without events in streaming mode")
+	}
+}
+
 func TestNewParser(t *testing.T) {
 	parser := New()
 	rType := fmt.Sprintf("%v", reflect.TypeOf(parser))
@@ -60,6 +158,66 @@ func TestNewParserChans(t *testing.T) {
 	}
 }
 
+func TestParserCloseClosesOutputChan(t *testing.T) {
+	parser := New()
+	parser.Load(recurringStandup)
+
+	closed := make(chan int)
+	go func() {
+		received := 0
+		for range parser.GetOutputChan() {
+			received++
+		}
+		closed <- received
+	}()
+
+	if err := parser.Close(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The output chan was not closed")
+	}
+	// closing twice is fine
+	parser.Close()
+}
+
+func TestParserContextCancelsDownloads(t *testing.T) {
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	parser := NewWithContext(ctx)
+	parser.GetInputChan() <- server.URL + "/slow.ics"
+	<-requested
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		parser.Wait()
+		parser.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The download was not cancelled")
+	}
+
+	parseErrors, _ := parser.GetErrors()
+	if len(parseErrors) != 1 {
+		t.Errorf("Expected 1 error, found %d in :\n  %#v", len(parseErrors), parseErrors)
+	}
+	if _, ok := <-parser.GetOutputChan(); ok {
+		t.Errorf("Expected the output chan to be closed")
+	}
+}
+
 func TestParsing0Calendars(t *testing.T) {
 	parser := New()
 	parser.Wait()
@@ -199,20 +357,122 @@ func TestParsingWrongCalendarUrls(t *testing.T) {
 	}
 }
 
-func TestCreatingTempDir(t *testing.T) {
-	FilePath = "testingTempDir/"
+func TestParseErrorsBySource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ics")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir ( %s )", err)
+	}
+	defer os.RemoveAll(dir)
+	broken := dir + "/broken.ics"
+	ioutil.WriteFile(broken, []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:2019-06-01\r\nRRULE:FREQ=SOMETIMES\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), 0644)
+	missing := dir + "/missing.ics"
+
+	parser := New()
+	input := parser.GetInputChan()
+	input <- broken
+	input <- missing
+	input <- "testCalendars/2eventsCal.ics"
+	parser.Wait()
+
+	parseErrors, _ := parser.GetErrors()
+	if len(parseErrors) != 3 {
+		t.Fatalf("Expected 3 errors, found %d in :\n  %#v", len(parseErrors), parseErrors)
+	}
+	for _, err := range parseErrors {
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Expected *ParseError, got %T", err)
+		}
+	}
+
+	brokenErrors := parser.GetErrorsForURL(broken)
+	if len(brokenErrors) != 2 {
+		t.Fatalf("Expected 2 errors for %s, found %v", broken, brokenErrors)
+	}
+	properties := map[string]int{}
+	for _, err := range brokenErrors {
+		if err.GetComponent() != "VEVENT" {
+			t.Errorf("Expected the error to be in VEVENT, got %q", err.GetComponent())
+		}
+		properties[err.GetProperty()] = err.GetLine()
+	}
+	if properties["DTSTART"] != 4 || properties["RRULE"] != 5 {
+		t.Errorf("Expected errors for DTSTART on line 4 and RRULE on line 5, got %v", properties)
+	}
+	if len(parser.GetErrorsForURL(missing)) != 1 || len(parser.GetErrorsForURL("testCalendars/2eventsCal.ics")) != 0 {
+		t.Errorf("Expected 1 error for the missing file and none for the valid one")
+	}
+
+	calendars, _ := parser.GetCalendars()
+	for _, cal := range calendars {
+		expected := 0
+		if cal.GetUrl() == broken {
+			expected = 2
+		}
+		if len(cal.GetErrors()) != expected {
+			t.Errorf("Expected %d errors in calendar %s, got %v", expected, cal.GetUrl(), cal.GetErrors())
+		}
+	}
+}
+
+func TestFetchers(t *testing.T) {
+	content, err := ioutil.ReadFile("testCalendars/2eventsCal.ics")
+	if err != nil {
+		t.Fatalf("Failed to read the test calendar ( %s )", err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cal.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+	host := server.URL[len("https://"):]
+
+	memory := NewMemoryFetcher().Set("team", string(content))
 	parser := New()
+	parser.RegisterFetcher("https", NewHTTPFetcher(server.Client()))
+	parser.RegisterFetcher("webcal", NewHTTPFetcher(server.Client()))
+	parser.RegisterFetcher("memory", memory)
+	parser.RegisterFetcher("S3", FetcherFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}))
+
 	input := parser.GetInputChan()
-	input <- "https://www.google.com/calendar/ical/yordanpulov%40gmail.com/private-81525ac0eb14cdc2e858c15e1b296a1c/basic.ics"
+	ok := []string{server.URL + "/cal.ics", "webcal://" + host + "/cal.ics", "memory://team", "s3://bucket/cal.ics", "file://" + mustAbs(t, "testCalendars/2eventsCal.ics")}
+	for _, url := range ok {
+		input <- url
+	}
+	failing := []string{server.URL + "/missing.ics", "memory://nobody", "ftp://example.com/cal.ics"}
+	for _, url := range failing {
+		input <- url
+	}
 	parser.Wait()
-	_, err := os.Stat(FilePath)
+
+	calendars, _ := parser.GetCalendars()
+	for _, cal := range calendars {
+		if len(cal.GetEvents()) != 2 {
+			t.Errorf("Expected 2 events from %s, got %d", cal.GetUrl(), len(cal.GetEvents()))
+		}
+	}
+	if len(calendars) != len(ok) {
+		t.Errorf("Expected %d calendars, found %d calendars", len(ok), len(calendars))
+	}
+	for _, url := range failing {
+		if len(parser.GetErrorsForURL(url)) != 1 {
+			t.Errorf("Expected 1 error for %s, got %v", url, parser.GetErrorsForURL(url))
+		}
+	}
+}
+
+// returns the absolute path of the file
+func mustAbs(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
 	if err != nil {
-		t.Errorf("Failed to create %s", FilePath)
+		t.Fatalf("Failed to get the path of %s ( %s )", path, err)
 	}
-	// remove the new dir
-	os.Remove(FilePath)
-	// return the var to default
-	FilePath = "tmp/"
+	return abs
 }
 
 func TestCalendarInfo(t *testing.T) {
@@ -319,14 +579,13 @@ func TestOutlookCalendarEventTimes(t *testing.T) {
 		t.Fatalf("The test calendar should have included at least one event")
 	}
 
-	// Sadly, expectedStart and expectedEnd are actually wrong given the TZID:
 	evt := evts[0]
-	expectedStart, err := time.Parse(time.RFC3339, "2017-10-24T08:00:00+02:00")
+	expectedStart, err := time.Parse(time.RFC3339, "2017-10-24T06:00:00+02:00")
 	if err != nil {
 		t.Fatalf("Failed to parse reference start: %s", err.Error())
 	}
 	start := evt.GetStart()
-	expectedEnd, err := time.Parse(time.RFC3339, "2017-10-24T10:00:00+02:00")
+	expectedEnd, err := time.Parse(time.RFC3339, "2017-10-24T08:00:00+02:00")
 	if err != nil {
 		t.Fatalf("Failed to parse reference end: %s", err.Error())
 	}
@@ -382,13 +641,14 @@ func TestCalendarEvents(t *testing.T) {
 	}
 
 	//  event must have
-	start, _ := time.Parse(IcsFormat, "20140714T100000Z")
-	end, _ := time.Parse(IcsFormat, "20140714T110000Z")
+	// the times are in Europe/Sofia
+	start, _ := time.Parse(IcsFormat, "20140714T070000Z")
+	end, _ := time.Parse(IcsFormat, "20140714T080000Z")
 	created, _ := time.Parse(IcsFormat, "20140515T075711Z")
 	modified, _ := time.Parse(IcsFormat, "20141125T074253Z")
 	location := "In The Office"
 	geo := NewGeo("39.620511", "-75.852557")
-	desc := "1. Report on previous weekly tasks. \\n2. Plan of the present weekly tasks."
+	desc := "1. Report on previous weekly tasks. \n2. Plan of the present weekly tasks."
 	seq := 1
 	status := "CONFIRMED"
 	summary := "General Operative Meeting"
@@ -399,11 +659,11 @@ func TestCalendarEvents(t *testing.T) {
 	org.SetName("r.chupetlovska@gmail.com")
 	org.SetEmail("r.chupetlovska@gmail.com")
 
-	if event.GetStart() != start {
+	if !event.GetStart().Equal(start) {
 		t.Errorf("Expected start %s, found %s", start, event.GetStart())
 	}
 
-	if event.GetEnd() != end {
+	if !event.GetEnd().Equal(end) {
 		t.Errorf("Expected end %s, found %s", end, event.GetEnd())
 	}
 
@@ -452,7 +712,7 @@ func TestCalendarEvents(t *testing.T) {
 	}
 
 	eventOrg := event.GetOrganizer()
-	if *eventOrg != *org {
+	if !reflect.DeepEqual(eventOrg, org) {
 		t.Errorf("Expected organizer %s, found %s", org, event.GetOrganizer())
 	}
 
@@ -723,3 +983,264 @@ func TestPagerDutyCalendarEventTimes(t *testing.T) {
 		t.Fatalf("End should be %s, but was %s", expectedEnd, end)
 	}
 }
+
+func TestRepeatRuleApply(t *testing.T) {
+	parser := New(WithRecurrenceExpansion(true))
+	parser.Load("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:weekly\r\nDTSTART:20190601T100000Z\r\nDTEND:20190601T110000Z\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=SA;COUNT=3\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+
+	calendars, err := parser.GetCalendars()
+	if err != nil {
+		t.Fatalf("Failed to get calendars ( %s )", err)
+	}
+	events := calendars[0].GetEvents()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	for i, expected := range []string{"20190601T100000Z", "20190615T100000Z", "20190629T100000Z"} {
+		if events[i].GetStart().Format(IcsFormat) != expected {
+			t.Errorf("Expected event %d to start at %s, got %s", i, expected, events[i].GetStart().Format(IcsFormat))
+		}
+	}
+}
+
+const recurringStandup = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID:20190610T090000Z\r\n" +
+	"DTSTART:20190610T110000Z\r\n" +
+	"DTEND:20190610T111500Z\r\n" +
+	"SUMMARY:Moved standup\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"DTSTART:20190603T090000Z\r\n" +
+	"DTEND:20190603T091500Z\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=10;BYDAY=MO\r\n" +
+	"EXDATE:20190617T090000Z,20190624T090000Z\r\n" +
+	"EXDATE:20190701T090000Z\r\n" +
+	"RDATE:20190605T090000Z\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestEventExDatesRDatesAndOverrides(t *testing.T) {
+	parser := New()
+	parser.Load(recurringStandup)
+
+	calendars, err := parser.GetCalendars()
+	if err != nil {
+		t.Fatalf("Failed to get calendars ( %s )", err)
+	}
+	event, err := calendars[0].GetEventByImportedID("standup")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+	if event.GetSummary() != "Standup" {
+		t.Fatalf("Expected the repeating event, got %s", event.GetSummary())
+	}
+	if len(event.GetExDates()) != 3 || len(event.GetRDates()) != 1 {
+		t.Errorf("Expected 3 EXDATE and 1 RDATE, got %d and %d", len(event.GetExDates()), len(event.GetRDates()))
+	}
+
+	expected := []string{
+		"20190603T090000Z Standup",
+		"20190605T090000Z Standup",
+		"20190610T110000Z Moved standup",
+		"20190708T090000Z Standup",
+	}
+	occurrences := event.Occurrences()
+	for i, exp := range expected {
+		occurrence, ok := occurrences.Next()
+		if !ok {
+			t.Fatalf("Expected %d occurrences, got %d", len(expected), i)
+		}
+		found := fmt.Sprintf("%s %s", occurrence.GetStart().Format(IcsFormat), occurrence.GetSummary())
+		if found != exp {
+			t.Errorf("Expected occurrence %s, got %s", exp, found)
+		}
+	}
+}
+
+func TestRepeatRuleApplyWithOverrides(t *testing.T) {
+	parser := New(WithRecurrenceExpansion(true))
+	parser.Load(recurringStandup)
+
+	calendars, err := parser.GetCalendars()
+	if err != nil {
+		t.Fatalf("Failed to get calendars ( %s )", err)
+	}
+	// 10 mondays without the 3 excluded , with the additional RDATE
+	events := calendars[0].GetEvents()
+	if len(events) != 8 {
+		t.Fatalf("Expected 8 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.GetStart().Format(IcsFormat) == "20190610T090000Z" {
+			t.Errorf("The moved instance should not be in the calendar")
+		}
+	}
+}
+
+func TestHTTPCacheReusesNotModifiedCalendars(t *testing.T) {
+	content, err := ioutil.ReadFile("testCalendars/2eventsCal.ics")
+	if err != nil {
+		t.Fatalf("Failed to read the test calendar ( %s )", err)
+	}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(content)
+	}))
+	defer server.Close()
+	url := server.URL + "/cal.ics"
+
+	dir, err := ioutil.TempDir("", "ics")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir ( %s )", err)
+	}
+	defer os.RemoveAll(dir)
+
+	parser := New().SetHTTPCache(NewDiskCache(dir))
+	for i := 0; i < 3; i++ {
+		parser.GetInputChan() <- url
+		parser.Wait()
+	}
+	calendars, _ := parser.GetCalendars()
+	if len(calendars) != 1 {
+		t.Errorf("Expected the calendar to be reused, found %d calendars", len(calendars))
+	}
+	if stats := parser.GetStats(); stats.GetCacheHits() != 2 || stats.GetCacheMisses() != 1 || downloads != 1 {
+		t.Errorf("Expected 2 hits , 1 miss and 1 download, got %d , %d and %d", stats.GetCacheHits(), stats.GetCacheMisses(), downloads)
+	}
+
+	// a new parser has no calendar to reuse , it downloads the calendar again
+	parser = New().SetHTTPCache(NewDiskCache(dir))
+	parser.GetInputChan() <- url
+	parser.Wait()
+	calendars, _ = parser.GetCalendars()
+	if len(calendars) != 1 || len(calendars[0].GetEvents()) != 2 {
+		t.Errorf("Expected the calendar to be parsed again")
+	}
+	if stats := parser.GetStats(); stats.GetCacheHits() != 0 || stats.GetCacheMisses() != 1 || downloads != 2 {
+		t.Errorf("Expected 0 hits , 1 miss and 2 downloads, got %d , %d and %d", stats.GetCacheHits(), stats.GetCacheMisses(), downloads)
+	}
+	if errs, _ := parser.GetErrors(); len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+}
+
+func TestCalendarProperties(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\n"+
+		"METHOD:request\r\n"+
+		"NAME:Team\\, Berlin\r\n"+
+		"X-WR-CALNAME:Team\r\n"+
+		"X-WR-CALDESC:Everything about the team\r\n"+
+		"REFRESH-INTERVAL;VALUE=DURATION:P1D\r\n"+
+		"X-PUBLISHED-TTL:PT1H\r\n"+
+		"COLOR:turquoise\r\n"+
+		"IMAGE;VALUE=URI;DISPLAY=BADGE;FMTTYPE=image/png:https://example.com/team.png\r\n"+
+		"SOURCE;VALUE=URI:https://example.com/team.ics\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	if cal.GetProdID() != "-//Google Inc//Google Calendar 70.9054//EN" || cal.GetMethod() != "REQUEST" || cal.GetCalScale() != "GREGORIAN" {
+		t.Errorf("Unexpected PRODID %s , METHOD %s or CALSCALE %s", cal.GetProdID(), cal.GetMethod(), cal.GetCalScale())
+	}
+	if cal.GetName() != "Team, Berlin" || cal.GetDesc() != "Everything about the team" {
+		t.Errorf("Unexpected name %q or description %q", cal.GetName(), cal.GetDesc())
+	}
+	if cal.GetRefreshInterval() != 24*time.Hour || cal.GetColor() != "turquoise" || cal.GetSource() != "https://example.com/team.ics" {
+		t.Errorf("Unexpected refresh interval %s , color %s or source %s", cal.GetRefreshInterval(), cal.GetColor(), cal.GetSource())
+	}
+	images := cal.GetImages()
+	if len(images) != 1 || images[0].GetURI() != "https://example.com/team.png" || images[0].GetDisplay() != "BADGE" || images[0].GetFmtType() != "image/png" {
+		t.Errorf("Unexpected images %v", images)
+	}
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	parsed := loadSingleCalendar(t, written)
+	if parsed.GetMethod() != "REQUEST" || parsed.GetName() != cal.GetName() || parsed.GetRefreshInterval() != 24*time.Hour || len(parsed.GetImages()) != 1 || parsed.GetSource() != cal.GetSource() {
+		t.Errorf("Expected the calendar properties to survive writing in\n%s", written)
+	}
+
+	ttl := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\nX-PUBLISHED-TTL:PT30M\r\nEND:VCALENDAR\r\n"))
+	if ttl.GetRefreshInterval() != 30*time.Minute {
+		t.Errorf("Expected X-PUBLISHED-TTL as refresh interval, got %s", ttl.GetRefreshInterval())
+	}
+}
+
+func TestEventStandardProperties(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:launch\r\n"+
+		"DTSTAMP:20190520T080000Z\r\n"+
+		"DTSTART:20190603T100000Z\r\n"+
+		"URL:https://example.com/launch\r\n"+
+		"PRIORITY:2\r\n"+
+		"TRANSP:TRANSPARENT\r\n"+
+		"CATEGORIES:Work,Launch\\, public\r\n"+
+		"CATEGORIES:Marketing\r\n"+
+		"COMMENT:Bring the slides\r\n"+
+		"COMMENT:Coffee\\; no tea\r\n"+
+		"CONTACT:Jane Doe\\, +1-555-0100\r\n"+
+		"RESOURCES:Projector,Room 1\r\n"+
+		"ATTACH:https://example.com/agenda.pdf\r\n"+
+		"ATTACH;FMTTYPE=text/plain;ENCODING=BASE64;VALUE=BINARY:aGVsbG8=\r\n"+
+		"RELATED-TO:release\r\n"+
+		"REQUEST-STATUS:2.0;Success\r\n"+
+		"REQUEST-STATUS:3.1;Invalid property value;DTSTART:96-Apr-01\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	event, err := cal.GetEventByImportedID("launch")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+	check := func(e *Event) {
+		t.Helper()
+		if e.GetURL() != "https://example.com/launch" || e.GetPriority() != 2 || e.GetTransp() != "TRANSPARENT" {
+			t.Errorf("Unexpected URL %s , PRIORITY %d or TRANSP %s", e.GetURL(), e.GetPriority(), e.GetTransp())
+		}
+		if !reflect.DeepEqual(e.GetCategories(), []string{"Work", "Launch, public", "Marketing"}) || !e.HasCategory("marketing") {
+			t.Errorf("Unexpected categories %q", e.GetCategories())
+		}
+		if !reflect.DeepEqual(e.GetComments(), []string{"Bring the slides", "Coffee; no tea"}) || !reflect.DeepEqual(e.GetContacts(), []string{"Jane Doe, +1-555-0100"}) {
+			t.Errorf("Unexpected comments %q or contacts %q", e.GetComments(), e.GetContacts())
+		}
+		if !reflect.DeepEqual(e.GetResources(), []string{"Projector", "Room 1"}) || !reflect.DeepEqual(e.GetRelatedTo(), []string{"release"}) {
+			t.Errorf("Unexpected resources %q or related to %q", e.GetResources(), e.GetRelatedTo())
+		}
+		attachments := e.GetAttachments()
+		if len(attachments) != 2 || attachments[0].GetURI() != "https://example.com/agenda.pdf" {
+			t.Fatalf("Unexpected attachments %v", attachments)
+		}
+		if data, err := attachments[1].GetData(); err != nil || string(data) != "hello" {
+			t.Errorf("Expected inline attachment hello, got %q ( %v )", data, err)
+		}
+		if !e.GetDTStamp().Equal(time.Date(2019, 5, 20, 8, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected DTSTAMP %s", e.GetDTStamp())
+		}
+		statuses := e.GetRequestStatuses()
+		if len(statuses) != 2 || !statuses[0].IsSuccess() || statuses[1].IsSuccess() || statuses[1].GetDescription() != "Invalid property value" || statuses[1].GetData() != "DTSTART:96-Apr-01" {
+			t.Errorf("Unexpected request statuses %v", statuses)
+		}
+	}
+	check(event)
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	rewritten, _ := loadSingleCalendar(t, written).GetEventByImportedID("launch")
+	check(rewritten)
+}
@@ -0,0 +1,144 @@
+package ics
+
+import (
+	"strings"
+)
+
+// Property is a raw content line of a component , its name , parameters and value as they were read.
+// The value is not unescaped
+type Property struct {
+	name   string
+	params []*contentParam
+	value  string
+}
+
+// creates new property , the name is case insensitive
+func NewProperty(name, value string) *Property {
+	return &Property{name: strings.ToUpper(name), value: value}
+}
+
+// copies the content line as property
+func newPropertyFromLine(cl *contentLine) *Property {
+	p := &Property{name: cl.name, value: cl.value}
+	for _, param := range cl.params {
+		p.params = append(p.params, &contentParam{name: param.name, values: append([]string{}, param.values...)})
+	}
+	return p
+}
+
+func (p *Property) GetName() string {
+	return p.name
+}
+
+func (p *Property) SetValue(value string) *Property {
+	p.value = value
+	return p
+}
+
+func (p *Property) GetValue() string {
+	return p.value
+}
+
+// sets the values of the parameter , replacing the old ones
+func (p *Property) SetParam(name string, values ...string) *Property {
+	name = strings.ToUpper(name)
+	for _, param := range p.params {
+		if param.name == name {
+			param.values = values
+			return p
+		}
+	}
+	p.params = append(p.params, &contentParam{name: name, values: values})
+	return p
+}
+
+// returns the first value of the parameter or an empty string
+func (p *Property) GetParam(name string) string {
+	return p.contentLine().param(name)
+}
+
+// returns all the values of the parameter
+func (p *Property) GetParamValues(name string) []string {
+	return p.contentLine().paramValues(name)
+}
+
+// returns the names of the parameters in the order they were read
+func (p *Property) GetParamNames() []string {
+	names := []string{}
+	for _, param := range p.params {
+		names = append(names, param.name)
+	}
+	return names
+}
+
+// returns the property as content line ready to be written
+func (p *Property) contentLine() *contentLine {
+	return &contentLine{name: p.name, params: p.params, value: p.value}
+}
+
+func (p *Property) String() string {
+	return p.contentLine().String()
+}
+
+// propertySet holds the raw properties of a component as they were read. It is embedded in
+// the calendar , the events , todos , journals , free/busy and alarms , so all of them have the same
+// getters for the raw properties , their chaining SetProperty and SetProperties call appendProperty
+// and replaceProperties. The unknown and X- properties are written back as they are
+type propertySet struct {
+	properties []*Property
+}
+
+func (ps *propertySet) appendProperty(p *Property) {
+	ps.properties = append(ps.properties, p)
+}
+
+func (ps *propertySet) replaceProperties(properties []*Property) {
+	ps.properties = properties
+}
+
+// returns the first raw property with the name or nil
+func (ps *propertySet) GetProperty(name string) *Property {
+	return findProperty(ps.properties, name)
+}
+
+// returns the raw properties with the name
+func (ps *propertySet) GetProperties(name string) []*Property {
+	return filterProperties(ps.properties, name)
+}
+
+// returns all the raw properties in the order they were read
+func (ps *propertySet) GetRawProperties() []*Property {
+	return ps.properties
+}
+
+// copies all the properties of the component in their order
+func rawProperties(c *component) []*Property {
+	properties := []*Property{}
+	for _, cl := range c.properties {
+		properties = append(properties, newPropertyFromLine(cl))
+	}
+	return properties
+}
+
+// returns the first property with the given name or nil
+func findProperty(properties []*Property, name string) *Property {
+	name = strings.ToUpper(name)
+	for _, p := range properties {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// returns all the properties with the given name
+func filterProperties(properties []*Property, name string) []*Property {
+	name = strings.ToUpper(name)
+	found := []*Property{}
+	for _, p := range properties {
+		if p.name == name {
+			found = append(found, p)
+		}
+	}
+	return found
+}
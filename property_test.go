@@ -0,0 +1,92 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+)
+
+const exchangeCal = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN\r\n" +
+	"METHOD:PUBLISH\r\n" +
+	"X-PUBLISHED-TTL:PT1H\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:planning\r\n" +
+	"DTSTART:20190603T100000Z\r\n" +
+	"DTEND:20190603T110000Z\r\n" +
+	"SUMMARY:Planning\r\n" +
+	"X-MICROSOFT-CDO-BUSYSTATUS:OOF\r\n" +
+	"X-GOOGLE-CONFERENCE:https://meet.google.com/abc-defg-hij\r\n" +
+	"X-APPLE-STRUCTURED-LOCATION;VALUE=URI;X-ADDRESS=\"1 Main St, Springfield\";X-TITLE=HQ:geo:37.33,-122.03\r\n" +
+	"X-MICROSOFT-CDO-BUSYSTATUS:BUSY\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT10M\r\n" +
+	"X-WR-ALARMUID:alarm-1\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestRawProperties(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(exchangeCal))
+	if prop := cal.GetProperty("method"); prop == nil || prop.GetValue() != "PUBLISH" {
+		t.Errorf("Expected METHOD:PUBLISH, got %v", prop)
+	}
+
+	event, err := cal.GetEventByImportedID("planning")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+	if event.GetProperty("SUMMARY").GetValue() != "Planning" {
+		t.Errorf("Expected the known properties to be kept too")
+	}
+	busy := event.GetProperties("X-MICROSOFT-CDO-BUSYSTATUS")
+	if len(busy) != 2 || busy[0].GetValue() != "OOF" || busy[1].GetValue() != "BUSY" {
+		t.Errorf("Unexpected properties %v", busy)
+	}
+	location := event.GetProperty("X-APPLE-STRUCTURED-LOCATION")
+	if location == nil || location.GetParam("x-address") != "1 Main St, Springfield" || location.GetValue() != "geo:37.33,-122.03" {
+		t.Fatalf("Unexpected property %v", location)
+	}
+	if names := location.GetParamNames(); len(names) != 3 || names[2] != "X-TITLE" {
+		t.Errorf("Unexpected parameters %v", names)
+	}
+	if event.GetProperty("X-MISSING") != nil || len(event.GetProperties("X-MISSING")) != 0 {
+		t.Errorf("Expected no property")
+	}
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	for _, line := range []string{
+		"METHOD:PUBLISH",
+		"X-PUBLISHED-TTL:PT1H",
+		"X-MICROSOFT-CDO-BUSYSTATUS:OOF",
+		"X-GOOGLE-CONFERENCE:https://meet.google.com/abc-defg-hij",
+		"X-WR-ALARMUID:alarm-1",
+	} {
+		if strings.Count(string(written), line+"\r\n") != 1 {
+			t.Errorf("Expected %s once in\n%s", line, written)
+		}
+	}
+	if strings.Count(string(written), "SUMMARY:") != 1 || strings.Count(string(written), "PRODID:") != 1 {
+		t.Errorf("Expected the known properties once in\n%s", written)
+	}
+
+	parsed := loadSingleCalendar(t, written)
+	rewritten, _ := parsed.GetEventByImportedID("planning")
+	if prop := rewritten.GetProperty("X-APPLE-STRUCTURED-LOCATION"); prop == nil || prop.String() != location.String() {
+		t.Errorf("Expected %v, got %v", location, prop)
+	}
+	if len(rewritten.GetProperties("X-MICROSOFT-CDO-BUSYSTATUS")) != 2 {
+		t.Errorf("Expected the repeated property to survive writing")
+	}
+
+	event.SetProperty(NewProperty("x-custom", "value").SetParam("x-param", "a", "b")).SetColor("red")
+	cal.SetProperty(NewProperty("X-WR-RELCALID", "team")).SetName("Team")
+	written, _ = cal.MarshalICS()
+	if !strings.Contains(string(written), "X-CUSTOM;X-PARAM=a,b:value\r\n") || !strings.Contains(string(written), "X-WR-RELCALID:team\r\n") {
+		t.Errorf("Expected the added property in\n%s", written)
+	}
+}
@@ -0,0 +1,234 @@
+package ics
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query finds the event instances of one or more calendars that match all of its filters.
+// The instances of the repeating events are included , the results are ordered by start
+type Query struct {
+	calendars  []*Calendar
+	from       time.Time
+	to         time.Time
+	predicates []func(e *Event) bool
+	limit      int
+	maxRepeats int
+}
+
+// creates new query over the events of the calendars
+func NewQuery(calendars ...*Calendar) *Query {
+	q := new(Query)
+	q.calendars = calendars
+	q.predicates = []func(e *Event) bool{}
+	q.maxRepeats = defaultMaxRepeats
+	return q
+}
+
+// creates new query over the events of the calendar
+func (c *Calendar) Query() *Query {
+	return NewQuery(c)
+}
+
+// creates new query over the events of all the calendars parsed by the parser
+func (p *Parser) Query() *Query {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return NewQuery(p.parsedCalendars...).MaxRepeats(p.maxRepeats)
+}
+
+// adds calendar to the calendars the query looks in
+func (q *Query) Calendar(cal *Calendar) *Query {
+	q.calendars = append(q.calendars, cal)
+	return q
+}
+
+// keeps the instances that overlap the time between from and to
+func (q *Query) Between(from, to time.Time) *Query {
+	q.from = from
+	q.to = to
+	return q
+}
+
+// keeps the instances that end after the time
+func (q *Query) From(from time.Time) *Query {
+	q.from = from
+	return q
+}
+
+// keeps the instances that start before the time
+func (q *Query) Until(to time.Time) *Query {
+	q.to = to
+	return q
+}
+
+// keeps the instances with one of the STATUS values like CONFIRMED , the case is ignored
+func (q *Query) WithStatus(statuses ...string) *Query {
+	return q.Where(func(e *Event) bool {
+		for _, status := range statuses {
+			if strings.EqualFold(e.GetStatus(), status) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// keeps the instances with attendee with the email , with or without mailto:
+func (q *Query) WithAttendee(email string) *Query {
+	return q.Where(func(e *Event) bool {
+		for _, attendee := range e.GetAttendees() {
+			if sameEmail(attendee.GetEmail(), email) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// keeps the instances organized by the email , with or without mailto:
+func (q *Query) WithOrganizer(email string) *Query {
+	return q.Where(func(e *Event) bool {
+		return e.GetOrganizer() != nil && sameEmail(e.GetOrganizer().GetEmail(), email)
+	})
+}
+
+// keeps the instances in the category , the case is ignored
+func (q *Query) WithCategory(category string) *Query {
+	return q.Where(func(e *Event) bool {
+		return e.HasCategory(category)
+	})
+}
+
+// keeps the instances for which the predicate returns true
+func (q *Query) Where(predicate func(e *Event) bool) *Query {
+	q.predicates = append(q.predicates, predicate)
+	return q
+}
+
+// returns at most n instances , the first ones by start
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// sets the max number of instances of a repeating event when the query has no end time
+func (q *Query) MaxRepeats(n int) *Query {
+	q.maxRepeats = n
+	return q
+}
+
+// Events runs the query and returns the matching instances ordered by start. The instances of the rules
+// that never end are limited to the max repeats per event when the query has no end time
+func (q *Query) Events() []*Event {
+	found := []*Event{}
+	for _, cal := range q.calendars {
+		cal.lock.Lock()
+		events := make([]*Event, 0, len(cal.events))
+		overrides := []*Event{}
+		for i := range cal.events {
+			// the instances are returned by the occurrences of the repeating event
+			if cal.events[i].generated {
+				continue
+			}
+			// the overrides are matched on their own , also the ones without repeating event or matching instance
+			if !cal.events[i].GetRecurrenceID().IsZero() {
+				overrides = append(overrides, cal.events[i])
+			} else {
+				events = append(events, cal.events[i])
+			}
+		}
+		cal.lock.Unlock()
+
+		for _, event := range events {
+			found = append(found, q.eventInstances(event)...)
+		}
+		for _, override := range overrides {
+			if (q.to.IsZero() || override.GetStart().Before(q.to)) && q.inTime(override) && q.matches(override) {
+				found = append(found, override)
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].GetStart().Before(found[j].GetStart()) })
+	if q.limit > 0 && len(found) > q.limit {
+		found = found[:q.limit]
+	}
+	return found
+}
+
+// returns the first matching instance , nil when there is none
+func (q *Query) First() *Event {
+	events := q.Limit(1).Events()
+	if len(events) == 0 {
+		return nil
+	}
+	return events[0]
+}
+
+// returns the matching instances of the event in the order of the occurrences
+func (q *Query) eventInstances(e *Event) []*Event {
+	instances := []*Event{}
+	inTime := 0
+	occurrences := e.Occurrences()
+	for {
+		instance, ok := occurrences.Next()
+		if !ok {
+			break
+		}
+		// the instances come in the order of the times they replace , an override may be moved after the end
+		slot := instance.GetStart()
+		if !instance.GetRecurrenceID().IsZero() {
+			slot = instance.GetRecurrenceID()
+		}
+		if !q.to.IsZero() && !slot.Before(q.to) {
+			break
+		}
+		if !q.inTime(instance) {
+			continue
+		}
+		// the rules without end are limited when the query has no end
+		if inTime++; q.to.IsZero() && inTime > q.maxRepeats {
+			break
+		}
+		// the overrides are matched with the other overrides of the calendar
+		if !instance.GetRecurrenceID().IsZero() || !q.matches(instance) {
+			continue
+		}
+		instances = append(instances, instance)
+		// the later instances of the event are not among the first ones
+		if q.limit > 0 && len(instances) >= q.limit {
+			break
+		}
+	}
+	return instances
+}
+
+// reports if the instance ends after the start of the query , the ones without duration
+// are matched by their start
+func (q *Query) inTime(instance *Event) bool {
+	if q.from.IsZero() {
+		return true
+	}
+	start, end := instance.GetStart(), instance.effectiveEnd()
+	if !end.After(start) {
+		return !start.Before(q.from)
+	}
+	return end.After(q.from)
+}
+
+// reports if the instance passes all the predicates
+func (q *Query) matches(instance *Event) bool {
+	for _, predicate := range q.predicates {
+		if !predicate(instance) {
+			return false
+		}
+	}
+	return true
+}
+
+// reports if the emails are the same , the mailto: prefix and the case are ignored
+func sameEmail(a, b string) bool {
+	return strings.EqualFold(trimMailto(a), trimMailto(b))
+}
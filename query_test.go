@@ -0,0 +1,147 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	work := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:standup\r\n"+
+		"DTSTART:20190603T090000Z\r\n"+
+		"DTEND:20190603T091500Z\r\n"+
+		"RRULE:FREQ=DAILY\r\n"+
+		"SUMMARY:Standup\r\n"+
+		"STATUS:CONFIRMED\r\n"+
+		"CATEGORIES:Team\r\n"+
+		"ATTENDEE;CN=Ann:mailto:ann@example.com\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:standup\r\n"+
+		"RECURRENCE-ID:20190605T090000Z\r\n"+
+		"DTSTART:20190605T140000Z\r\n"+
+		"DTEND:20190605T141500Z\r\n"+
+		"SUMMARY:Late standup\r\n"+
+		"STATUS:TENTATIVE\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:review\r\n"+
+		"DTSTART:20190604T130000Z\r\n"+
+		"DTEND:20190604T150000Z\r\n"+
+		"SUMMARY:Review\r\n"+
+		"STATUS:confirmed\r\n"+
+		"ORGANIZER:mailto:bob@example.com\r\n"+
+		"ATTENDEE:mailto:ANN@example.com\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+	home := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:dentist\r\n"+
+		"DTSTART:20190604T140000Z\r\n"+
+		"DTEND:20190604T143000Z\r\n"+
+		"SUMMARY:Dentist\r\n"+
+		"CATEGORIES:Health\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:holiday\r\n"+
+		"DTSTART;VALUE=DATE:20190606\r\n"+
+		"SUMMARY:Holiday\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	summaries := func(events []*Event) []string {
+		found := []string{}
+		for _, e := range events {
+			found = append(found, e.GetSummary()+" "+e.GetStart().UTC().Format("02 15:04"))
+		}
+		return found
+	}
+	day := func(d int) time.Time {
+		return time.Date(2019, time.June, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		query    *Query
+		expected []string
+	}{
+		{"between", work.Query().Between(day(4), day(6)), []string{"Standup 04 09:00", "Review 04 13:00", "Late standup 05 14:00"}},
+		{"overlap", NewQuery(work, home).Between(day(4).Add(14*time.Hour+15*time.Minute), day(4).Add(15*time.Hour+30*time.Minute)), []string{"Review 04 13:00", "Dentist 04 14:00"}},
+		{"status", work.Query().Between(day(3), day(6)).WithStatus("CONFIRMED"), []string{"Standup 03 09:00", "Standup 04 09:00", "Review 04 13:00"}},
+		{"attendee", work.Query().Until(day(5)).WithAttendee("ann@example.com"), []string{"Standup 03 09:00", "Standup 04 09:00", "Review 04 13:00"}},
+		{"organizer", work.Query().WithOrganizer("mailto:Bob@example.com"), []string{"Review 04 13:00"}},
+		{"category", NewQuery(work).Calendar(home).Between(day(1), day(30)).WithCategory("health"), []string{"Dentist 04 14:00"}},
+		{"whole day", home.Query().Between(day(6).Add(12*time.Hour), day(7)), []string{"Holiday 06 00:00"}},
+		{"limit", NewQuery(work, home).From(day(4).Add(12 * time.Hour)).Limit(4), []string{"Review 04 13:00", "Dentist 04 14:00", "Late standup 05 14:00", "Holiday 06 00:00"}},
+		{"predicate", work.Query().Between(day(3), day(10)).Where(func(e *Event) bool { return e.GetStart().Weekday() == time.Saturday }), []string{"Standup 08 09:00"}},
+	}
+	for _, tc := range testCases {
+		got := summaries(tc.query.Events())
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: Expected %v, found %v", tc.name, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: Expected %v, found %v", tc.name, tc.expected, got)
+				break
+			}
+		}
+	}
+
+	// the rules without end are limited without end of the query
+	standups := work.Query().Where(func(e *Event) bool { return e.GetImportedID() == "standup" })
+	if count := len(standups.Events()); count != defaultMaxRepeats {
+		t.Errorf("Expected %d standups, found %d", defaultMaxRepeats, count)
+	}
+	if first := home.Query().From(day(5)).First(); first == nil || first.GetSummary() != "Holiday" {
+		t.Errorf("Unexpected first event %v", first)
+	}
+	if first := home.Query().From(day(7)).First(); first != nil {
+		t.Errorf("Expected no event, found %v", first)
+	}
+
+	moved := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"DTSTART:20190101T090000Z\r\n"+
+		"DTEND:20190101T100000Z\r\n"+
+		"RRULE:FREQ=DAILY;COUNT=10\r\n"+
+		"SUMMARY:Daily\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"RECURRENCE-ID:20190102T090000Z\r\n"+
+		"DTSTART:20190202T090000Z\r\n"+
+		"DTEND:20190202T100000Z\r\n"+
+		"SUMMARY:Moved\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"RECURRENCE-ID:20190120T090000Z\r\n"+
+		"DTSTART:20190120T090000Z\r\n"+
+		"SUMMARY:Extra\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:invitation\r\n"+
+		"RECURRENCE-ID:20190105T140000Z\r\n"+
+		"DTSTART:20190105T150000Z\r\n"+
+		"SUMMARY:Invitation\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+	january := func(d int) time.Time {
+		return time.Date(2019, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+	// the moved instance does not stop the later ones , the overrides without repeating event or instance are kept
+	got := summaries(moved.Query().Between(january(1), january(21)).Events())
+	if len(got) != 11 || got[0] != "Daily 01 09:00" || got[1] != "Daily 03 09:00" || got[4] != "Invitation 05 15:00" || got[10] != "Extra 20 09:00" {
+		t.Errorf("Unexpected instances %v", got)
+	}
+	if got := summaries(moved.Query().Between(january(1), january(15)).Where(func(e *Event) bool { return e.GetImportedID() == "daily" }).Events()); len(got) != 9 {
+		t.Errorf("Expected 9 instances before the moved one, found %v", got)
+	}
+	if got := summaries(moved.Query().Between(time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, time.February, 3, 0, 0, 0, 0, time.UTC)).Events()); len(got) != 1 || got[0] != "Moved 02 09:00" {
+		t.Errorf("Expected the moved instance, found %v", got)
+	}
+}
@@ -0,0 +1,797 @@
+package ics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency int
+
+const (
+	Secondly Frequency = iota
+	Minutely
+	Hourly
+	Daily
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{
+	Secondly: "SECONDLY",
+	Minutely: "MINUTELY",
+	Hourly:   "HOURLY",
+	Daily:    "DAILY",
+	Weekly:   "WEEKLY",
+	Monthly:  "MONTHLY",
+	Yearly:   "YEARLY",
+}
+
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+// ics names of the week days
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// parses ics week day name (MO, TU ...) to time.Weekday
+func parseWeekday(name string) (time.Weekday, error) {
+	for day, dayName := range weekdayNames {
+		if dayName == name {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid week day %q", name)
+}
+
+// WeekdayNum is a BYDAY value like MO , 2TU or -1FR
+type WeekdayNum struct {
+	weekday time.Weekday
+	n       int
+}
+
+// NewWeekdayNum creates a BYDAY value , n is 0 for every weekday in the period
+func NewWeekdayNum(weekday time.Weekday, n int) WeekdayNum {
+	return WeekdayNum{weekday: weekday, n: n}
+}
+
+func (w WeekdayNum) GetWeekday() time.Weekday {
+	return w.weekday
+}
+
+// returns the position of the weekday in the month or year , 0 when every weekday matches
+func (w WeekdayNum) GetN() int {
+	return w.n
+}
+
+func (w WeekdayNum) String() string {
+	if w.n == 0 {
+		return weekdayNames[w.weekday]
+	}
+	return fmt.Sprintf("%d%s", w.n, weekdayNames[w.weekday])
+}
+
+// RecurrenceRule is a parsed RRULE value as described in RFC 5545 section 3.3.10
+type RecurrenceRule struct {
+	freq       Frequency
+	interval   int
+	count      int
+	until      time.Time
+	untilUTC   bool
+	untilDate  bool
+	bySecond   []int
+	byMinute   []int
+	byHour     []int
+	byDay      []WeekdayNum
+	byMonthDay []int
+	byYearDay  []int
+	byWeekNo   []int
+	byMonth    []int
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// ParseRecurrenceRule parses RRULE value like FREQ=MONTHLY;BYDAY=-1FR;COUNT=5
+func ParseRecurrenceRule(rrule string) (*RecurrenceRule, error) {
+	r := &RecurrenceRule{interval: 1, weekStart: time.Monday}
+	freqFound := false
+
+	for _, part := range strings.Split(strings.TrimSpace(rrule), ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		name, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch name {
+		case "FREQ":
+			freqFound = false
+			for freq, freqName := range frequencyNames {
+				if freqName == value {
+					r.freq = freq
+					freqFound = true
+				}
+			}
+			if !freqFound {
+				err = fmt.Errorf("invalid FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("invalid INTERVAL %q", value)
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err == nil && r.count < 1 {
+				err = fmt.Errorf("invalid COUNT %q", value)
+			}
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYSECOND":
+			r.bySecond, err = parseIntList(value, 0, 60, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseIntList(value, 0, 59, false)
+		case "BYHOUR":
+			r.byHour, err = parseIntList(value, 0, 23, false)
+		case "BYDAY":
+			r.byDay, err = parseWeekdayNumList(value)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseIntList(value, 1, 31, true)
+		case "BYYEARDAY":
+			r.byYearDay, err = parseIntList(value, 1, 366, true)
+		case "BYWEEKNO":
+			r.byWeekNo, err = parseIntList(value, 1, 53, true)
+		case "BYMONTH":
+			r.byMonth, err = parseIntList(value, 1, 12, false)
+		case "BYSETPOS":
+			r.bySetPos, err = parseIntList(value, 1, 366, true)
+		case "WKST":
+			r.weekStart, err = parseWeekday(value)
+		default:
+			// x-name rule parts are allowed and ignored
+			if !strings.HasPrefix(name, "X-") {
+				err = fmt.Errorf("unknown rule part %q", name)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !freqFound {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL can not be used together")
+	}
+	return r, nil
+}
+
+// parses the UNTIL value , it may be a date , an UTC or a floating date time
+func (r *RecurrenceRule) parseUntil(value string) error {
+	var err error
+	switch {
+	case len(value) == len(IcsFormatWholeDay):
+		r.until, err = time.Parse(IcsFormatWholeDay, value)
+		r.untilDate = true
+	case strings.HasSuffix(value, "Z"):
+		r.until, err = time.Parse(IcsFormat, value)
+		r.untilUTC = true
+	default:
+		r.until, err = time.Parse(IcsFormat, value+"Z")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid UNTIL %q", value)
+	}
+	return nil
+}
+
+// parses comma separated numbers in [min, max] (or [-max, -min] when negative values are allowed)
+func parseIntList(value string, min, max int, allowNegative bool) ([]int, error) {
+	list := []int{}
+	for _, v := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(strings.TrimPrefix(v, "+"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", v)
+		}
+		abs := i
+		if abs < 0 && allowNegative {
+			abs = -abs
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("number %d out of range", i)
+		}
+		list = append(list, i)
+	}
+	return list, nil
+}
+
+// parses comma separated BYDAY values
+func parseWeekdayNumList(value string) ([]WeekdayNum, error) {
+	list := []WeekdayNum{}
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid week day %q", v)
+		}
+		day, err := parseWeekday(v[len(v)-2:])
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		if len(v) > 2 {
+			n, err = strconv.Atoi(strings.TrimPrefix(v[:len(v)-2], "+"))
+			if err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, fmt.Errorf("invalid week day %q", v)
+			}
+		}
+		list = append(list, WeekdayNum{weekday: day, n: n})
+	}
+	return list, nil
+}
+
+func (r *RecurrenceRule) GetFrequency() Frequency {
+	return r.freq
+}
+
+func (r *RecurrenceRule) GetInterval() int {
+	return r.interval
+}
+
+// returns the COUNT part , 0 when it is not set
+func (r *RecurrenceRule) GetCount() int {
+	return r.count
+}
+
+// returns the UNTIL part , zero time when it is not set.
+// floating and date values are returned in UTC
+func (r *RecurrenceRule) GetUntil() time.Time {
+	return r.until
+}
+
+func (r *RecurrenceRule) GetBySecond() []int {
+	return r.bySecond
+}
+
+func (r *RecurrenceRule) GetByMinute() []int {
+	return r.byMinute
+}
+
+func (r *RecurrenceRule) GetByHour() []int {
+	return r.byHour
+}
+
+func (r *RecurrenceRule) GetByDay() []WeekdayNum {
+	return r.byDay
+}
+
+func (r *RecurrenceRule) GetByMonthDay() []int {
+	return r.byMonthDay
+}
+
+func (r *RecurrenceRule) GetByYearDay() []int {
+	return r.byYearDay
+}
+
+func (r *RecurrenceRule) GetByWeekNo() []int {
+	return r.byWeekNo
+}
+
+func (r *RecurrenceRule) GetByMonth() []int {
+	return r.byMonth
+}
+
+func (r *RecurrenceRule) GetBySetPos() []int {
+	return r.bySetPos
+}
+
+func (r *RecurrenceRule) GetWeekStart() time.Weekday {
+	return r.weekStart
+}
+
+// formats the rule back to RRULE value
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.freq.String()}
+	if r.interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.interval))
+	}
+	if r.count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.count))
+	}
+	if !r.until.IsZero() {
+		switch {
+		case r.untilDate:
+			parts = append(parts, "UNTIL="+r.until.Format(IcsFormatWholeDay))
+		case r.untilUTC:
+			parts = append(parts, "UNTIL="+r.until.Format(IcsFormat))
+		default:
+			parts = append(parts, "UNTIL="+strings.TrimSuffix(r.until.Format(IcsFormat), "Z"))
+		}
+	}
+	ints := func(name string, list []int) {
+		if len(list) == 0 {
+			return
+		}
+		values := make([]string, len(list))
+		for i, v := range list {
+			values[i] = strconv.Itoa(v)
+		}
+		parts = append(parts, name+"="+strings.Join(values, ","))
+	}
+	ints("BYSECOND", r.bySecond)
+	ints("BYMINUTE", r.byMinute)
+	ints("BYHOUR", r.byHour)
+	if len(r.byDay) > 0 {
+		values := make([]string, len(r.byDay))
+		for i, v := range r.byDay {
+			values[i] = v.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(values, ","))
+	}
+	ints("BYMONTHDAY", r.byMonthDay)
+	ints("BYYEARDAY", r.byYearDay)
+	ints("BYWEEKNO", r.byWeekNo)
+	ints("BYMONTH", r.byMonth)
+	ints("BYSETPOS", r.bySetPos)
+	if r.weekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.weekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterator returns the start times generated by the rule for the given DTSTART.
+// The times are in the location of start
+func (r *RecurrenceRule) Iterator(start time.Time) *RecurrenceIterator {
+	it := &RecurrenceIterator{rule: r, start: start, loc: start.Location()}
+
+	// the UNTIL value is in the time zone of start when it is not UTC
+	if !r.until.IsZero() {
+		u := r.until
+		switch {
+		case r.untilDate:
+			it.until = time.Date(u.Year(), u.Month(), u.Day(), 23, 59, 59, 0, it.loc)
+		case r.untilUTC:
+			it.until = u
+		default:
+			it.until = time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, it.loc)
+		}
+	}
+
+	// the rule parts that are not set are taken from start
+	it.byMonth = r.byMonth
+	it.byMonthDay = r.byMonthDay
+	it.byDay = r.byDay
+	noDayFilter := len(r.byWeekNo) == 0 && len(r.byYearDay) == 0 && len(r.byMonthDay) == 0 && len(r.byDay) == 0
+	switch {
+	case r.freq == Yearly && noDayFilter:
+		if len(it.byMonth) == 0 {
+			it.byMonth = []int{int(start.Month())}
+		}
+		it.byMonthDay = []int{start.Day()}
+	case r.freq == Monthly && noDayFilter:
+		it.byMonthDay = []int{start.Day()}
+	case r.freq == Weekly && noDayFilter:
+		it.byDay = []WeekdayNum{{weekday: start.Weekday()}}
+	}
+	it.byHour = r.byHour
+	if len(it.byHour) == 0 && r.freq > Hourly {
+		it.byHour = []int{start.Hour()}
+	}
+	it.byMinute = r.byMinute
+	if len(it.byMinute) == 0 && r.freq > Minutely {
+		it.byMinute = []int{start.Minute()}
+	}
+	it.bySecond = r.bySecond
+	if len(it.bySecond) == 0 && r.freq > Secondly {
+		it.bySecond = []int{start.Second()}
+	}
+
+	// the first period is the one that contains start
+	y, m, d := start.Date()
+	switch r.freq {
+	case Yearly:
+		it.cursor = time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	case Monthly:
+		it.cursor = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		it.cursor = time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case Daily:
+		it.cursor = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case Hourly:
+		it.cursor = time.Date(y, m, d, start.Hour(), 0, 0, 0, time.UTC)
+	case Minutely:
+		it.cursor = time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, time.UTC)
+	case Secondly:
+		it.cursor = time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+	}
+	return it
+}
+
+// rules that never match anything are stopped after so many periods without an occurrence
+const maxEmptyPeriods = 1000000
+
+// RecurrenceIterator walks the occurrences of a recurrence rule in order
+type RecurrenceIterator struct {
+	rule  *RecurrenceRule
+	start time.Time
+	until time.Time
+	loc   *time.Location
+
+	byMonth    []int
+	byMonthDay []int
+	byDay      []WeekdayNum
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+
+	// the beginning of the current period as floating time in UTC
+	cursor       time.Time
+	emptyPeriods int
+	buffer       []time.Time
+	emitted      int
+	done         bool
+}
+
+// Next returns the next occurrence , the second value is false when there are no more
+func (it *RecurrenceIterator) Next() (time.Time, bool) {
+	for len(it.buffer) == 0 {
+		if it.done {
+			return time.Time{}, false
+		}
+		if it.cursor.Year() > 9999 || it.emptyPeriods > maxEmptyPeriods {
+			it.done = true
+			return time.Time{}, false
+		}
+
+		// periods shorter than a day are skipped to the next day if the day does not match
+		if it.rule.freq < Daily && !it.dayMatches(it.cursor) {
+			it.skipDay()
+			it.emptyPeriods++
+			continue
+		}
+
+		for _, t := range it.expandPeriod() {
+			if !t.Before(it.start) {
+				it.buffer = append(it.buffer, t)
+			}
+		}
+		if len(it.buffer) > 0 {
+			it.emptyPeriods = 0
+		} else {
+			it.emptyPeriods++
+		}
+		it.advance()
+	}
+
+	t := it.buffer[0]
+	it.buffer = it.buffer[1:]
+
+	if (!it.until.IsZero() && t.After(it.until)) || (it.rule.count > 0 && it.emitted >= it.rule.count) {
+		it.done = true
+		it.buffer = nil
+		return time.Time{}, false
+	}
+	it.emitted++
+	return t, true
+}
+
+// moves the cursor to the next period
+func (it *RecurrenceIterator) advance() {
+	interval := it.rule.interval
+	switch it.rule.freq {
+	case Yearly:
+		it.cursor = it.cursor.AddDate(interval, 0, 0)
+	case Monthly:
+		it.cursor = it.cursor.AddDate(0, interval, 0)
+	case Weekly:
+		it.cursor = it.cursor.AddDate(0, 0, 7*interval)
+	case Daily:
+		it.cursor = it.cursor.AddDate(0, 0, interval)
+	case Hourly:
+		it.cursor = it.cursor.Add(time.Duration(interval) * time.Hour)
+	case Minutely:
+		it.cursor = it.cursor.Add(time.Duration(interval) * time.Minute)
+	case Secondly:
+		it.cursor = it.cursor.Add(time.Duration(interval) * time.Second)
+	}
+}
+
+// moves the cursor of a rule with period shorter than a day to the first period in the next day
+func (it *RecurrenceIterator) skipDay() {
+	y, m, d := it.cursor.Date()
+	nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+	step := time.Duration(it.rule.interval) * time.Second
+	switch it.rule.freq {
+	case Hourly:
+		step = time.Duration(it.rule.interval) * time.Hour
+	case Minutely:
+		step = time.Duration(it.rule.interval) * time.Minute
+	}
+	steps := (nextDay.Sub(it.cursor) + step - 1) / step
+	it.cursor = it.cursor.Add(steps * step)
+}
+
+// returns the sorted occurrences in the current period
+func (it *RecurrenceIterator) expandPeriod() []time.Time {
+	r := it.rule
+
+	// the days of the period
+	var first, last time.Time
+	switch r.freq {
+	case Yearly:
+		first = it.cursor
+		last = first.AddDate(1, 0, -1)
+	case Monthly:
+		first = it.cursor
+		last = first.AddDate(0, 1, -1)
+	case Weekly:
+		first = it.cursor
+		last = first.AddDate(0, 0, 6)
+	default:
+		y, m, d := it.cursor.Date()
+		first = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		last = first
+	}
+
+	// the times of the day
+	hours, minutes, seconds := it.byHour, it.byMinute, it.bySecond
+	switch r.freq {
+	case Hourly:
+		hours = limitTo(it.cursor.Hour(), r.byHour)
+	case Minutely:
+		hours = limitTo(it.cursor.Hour(), r.byHour)
+		minutes = limitTo(it.cursor.Minute(), r.byMinute)
+	case Secondly:
+		hours = limitTo(it.cursor.Hour(), r.byHour)
+		minutes = limitTo(it.cursor.Minute(), r.byMinute)
+		seconds = limitTo(it.cursor.Second(), r.bySecond)
+	}
+	hours, minutes, seconds = sortedInts(hours), sortedInts(minutes), sortedInts(seconds)
+
+	set := []time.Time{}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !it.dayMatches(day) {
+			continue
+		}
+		for _, h := range hours {
+			for _, mi := range minutes {
+				for _, s := range seconds {
+					set = append(set, time.Date(day.Year(), day.Month(), day.Day(), h, mi, s, 0, it.loc))
+				}
+			}
+		}
+	}
+
+	if len(r.bySetPos) > 0 {
+		set = selectSetPos(set, r.bySetPos)
+	}
+	return set
+}
+
+// returns the value in a list when it is allowed by the limit (every value is allowed by an empty limit)
+func limitTo(value int, limit []int) []int {
+	if len(limit) == 0 || containsInt(limit, value) {
+		return []int{value}
+	}
+	return nil
+}
+
+// checks the day against the BYMONTH , BYWEEKNO , BYYEARDAY , BYMONTHDAY and BYDAY parts
+func (it *RecurrenceIterator) dayMatches(day time.Time) bool {
+	r := it.rule
+
+	if len(it.byMonth) > 0 && !containsInt(it.byMonth, int(day.Month())) {
+		return false
+	}
+
+	if len(r.byWeekNo) > 0 {
+		weekNo, weeksInYear := isoWeek(day, r.weekStart)
+		if !containsInt(r.byWeekNo, weekNo) && !containsInt(r.byWeekNo, weekNo-weeksInYear-1) {
+			return false
+		}
+	}
+
+	daysInYear := time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	if len(r.byYearDay) > 0 {
+		yday := day.YearDay()
+		if !containsInt(r.byYearDay, yday) && !containsInt(r.byYearDay, yday-daysInYear-1) {
+			return false
+		}
+	}
+
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(it.byMonthDay) > 0 {
+		if !containsInt(it.byMonthDay, day.Day()) && !containsInt(it.byMonthDay, day.Day()-daysInMonth-1) {
+			return false
+		}
+	}
+
+	if len(it.byDay) > 0 {
+		// the position is counted in the month for MONTHLY rules and YEARLY rules with BYMONTH
+		inMonth := r.freq == Monthly || (r.freq == Yearly && len(r.byMonth) > 0)
+		ordinal := r.freq == Monthly || r.freq == Yearly
+
+		matched := false
+		for _, wd := range it.byDay {
+			if wd.weekday != day.Weekday() {
+				continue
+			}
+			if wd.n == 0 || !ordinal {
+				matched = true
+				break
+			}
+			var n, nFromEnd int
+			if inMonth {
+				n = (day.Day()-1)/7 + 1
+				nFromEnd = -((daysInMonth-day.Day())/7 + 1)
+			} else {
+				n = (day.YearDay()-1)/7 + 1
+				nFromEnd = -((daysInYear-day.YearDay())/7 + 1)
+			}
+			if wd.n == n || wd.n == nFromEnd {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// returns the week number of the day and the number of weeks in the year the week belongs to.
+// week 1 is the first week with at least 4 days in the year
+func isoWeek(day time.Time, weekStart time.Weekday) (int, int) {
+	year := day.Year()
+	start := firstWeekStart(year, weekStart)
+	if day.Before(start) {
+		year--
+		start = firstWeekStart(year, weekStart)
+	} else if next := firstWeekStart(year+1, weekStart); !day.Before(next) {
+		year++
+		start = next
+	}
+	weeks := int(firstWeekStart(year+1, weekStart).Sub(start).Hours()/24) / 7
+	return int(day.Sub(start).Hours()/24)/7 + 1, weeks
+}
+
+// returns the first day of week 1 of the year
+func firstWeekStart(year int, weekStart time.Weekday) time.Time {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan1.Weekday()) - int(weekStart) + 7) % 7
+	if offset <= 3 {
+		return jan1.AddDate(0, 0, -offset)
+	}
+	return jan1.AddDate(0, 0, 7-offset)
+}
+
+// returns the times at the BYSETPOS positions of the period set
+func selectSetPos(set []time.Time, positions []int) []time.Time {
+	selected := []time.Time{}
+	for _, pos := range positions {
+		i := pos - 1
+		if pos < 0 {
+			i = len(set) + pos
+		}
+		if i < 0 || i >= len(set) {
+			continue
+		}
+		duplicate := false
+		for _, t := range selected {
+			if t.Equal(set[i]) {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			selected = append(selected, set[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return selected
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedInts(list []int) []int {
+	sorted := append([]int{}, list...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+// OccurrenceIterator walks the instances of a repeating event
+type OccurrenceIterator struct {
+	event    *Event
+	duration time.Duration
+	rule     *RecurrenceIterator
+	rDates   []time.Time
+	// the next time from the rule that is not returned yet
+	ruleNext *time.Time
+	last     time.Time
+	started  bool
+}
+
+// Next returns the next instance of the event , the second value is false when there are no more.
+// The first instance is the event itself , the others are copies of it with moved start and end
+func (it *OccurrenceIterator) Next() (*Event, bool) {
+	for {
+		start, ok := it.nextStart()
+		if !ok {
+			return nil, false
+		}
+		if it.event.isExcluded(start) {
+			continue
+		}
+		if cal := it.event.GetCalendar(); cal != nil && it.event.GetRecurrenceID().IsZero() {
+			if override := cal.getOverride(it.event.GetImportedID(), start); override != nil {
+				return override, true
+			}
+		}
+		if start.Equal(it.event.GetStart()) {
+			return it.event, true
+		}
+		return it.event.newOccurrence(start, start.Add(it.duration)), true
+	}
+}
+
+// returns the next start time from DTSTART , RRULE and RDATE in order and without repeats
+func (it *OccurrenceIterator) nextStart() (time.Time, bool) {
+	if !it.started {
+		it.started = true
+		it.last = it.event.GetStart()
+		return it.last, true
+	}
+
+	for {
+		if it.ruleNext == nil && it.rule != nil {
+			if t, ok := it.rule.Next(); ok {
+				it.ruleNext = &t
+			} else {
+				it.rule = nil
+			}
+		}
+
+		var next time.Time
+		switch {
+		case it.ruleNext != nil && (len(it.rDates) == 0 || !it.rDates[0].Before(*it.ruleNext)):
+			next = *it.ruleNext
+			it.ruleNext = nil
+		case len(it.rDates) > 0:
+			next = it.rDates[0]
+			it.rDates = it.rDates[1:]
+		default:
+			return time.Time{}, false
+		}
+
+		// instances before DTSTART are not part of the set
+		if next.After(it.last) {
+			it.last = next
+			return next, true
+		}
+	}
+}
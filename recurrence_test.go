@@ -0,0 +1,157 @@
+package ics
+
+import (
+	"testing"
+	"time"
+)
+
+// returns the first n occurrences of the rule formatted as ics date times
+func occurrencesOf(t *testing.T, rrule, dtstart string, n int) []string {
+	rule, err := ParseRecurrenceRule(rrule)
+	if err != nil {
+		t.Fatalf("Failed to parse %s ( %s )", rrule, err)
+	}
+	start, err := time.Parse(IcsFormat, dtstart)
+	if err != nil {
+		t.Fatalf("Failed to parse %s ( %s )", dtstart, err)
+	}
+
+	found := []string{}
+	it := rule.Iterator(start)
+	for len(found) < n {
+		occurrence, ok := it.Next()
+		if !ok {
+			break
+		}
+		found = append(found, occurrence.Format(IcsFormat))
+	}
+	return found
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	cases := []struct {
+		rrule    string
+		dtstart  string
+		limit    int
+		expected []string
+	}{
+		{"FREQ=DAILY;COUNT=3", "19970902T090000Z", 10,
+			[]string{"19970902T090000Z", "19970903T090000Z", "19970904T090000Z"}},
+		{"FREQ=DAILY;INTERVAL=2", "19970902T090000Z", 3,
+			[]string{"19970902T090000Z", "19970904T090000Z", "19970906T090000Z"}},
+		{"FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=TU,TH;COUNT=8", "19970902T090000Z", 20,
+			[]string{"19970902T090000Z", "19970904T090000Z", "19970916T090000Z", "19970918T090000Z", "19970930T090000Z", "19971002T090000Z", "19971014T090000Z", "19971016T090000Z"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", "19970805T090000Z", 10,
+			[]string{"19970805T090000Z", "19970810T090000Z", "19970819T090000Z", "19970824T090000Z"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", "19970805T090000Z", 10,
+			[]string{"19970805T090000Z", "19970817T090000Z", "19970819T090000Z", "19970831T090000Z"}},
+		{"FREQ=WEEKLY;BYDAY=SA;COUNT=2", "20190601T100000Z", 10,
+			[]string{"20190601T100000Z", "20190608T100000Z"}},
+		{"FREQ=MONTHLY;COUNT=6;BYDAY=1FR", "19970905T090000Z", 10,
+			[]string{"19970905T090000Z", "19971003T090000Z", "19971107T090000Z", "19971205T090000Z", "19980102T090000Z", "19980206T090000Z"}},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "19970929T090000Z", 4,
+			[]string{"19970930T090000Z", "19971031T090000Z", "19971128T090000Z", "19971231T090000Z"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-3", "19970928T090000Z", 4,
+			[]string{"19970928T090000Z", "19971029T090000Z", "19971128T090000Z", "19971229T090000Z"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5", "20070115T090000Z", 10,
+			[]string{"20070115T090000Z", "20070130T090000Z", "20070215T090000Z", "20070315T090000Z", "20070330T090000Z"}},
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", "19970902T090000Z", 3,
+			[]string{"19980213T090000Z", "19980313T090000Z", "19981113T090000Z"}},
+		{"FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO", "19970512T090000Z", 3,
+			[]string{"19970512T090000Z", "19980511T090000Z", "19990517T090000Z"}},
+		{"FREQ=YEARLY;INTERVAL=3;COUNT=7;BYYEARDAY=1,100,200", "19970101T090000Z", 10,
+			[]string{"19970101T090000Z", "19970410T090000Z", "19970719T090000Z", "20000101T090000Z", "20000409T090000Z", "20000718T090000Z", "20030101T090000Z"}},
+		{"FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU", "19701025T040000Z", 3,
+			[]string{"19701025T040000Z", "19711031T040000Z", "19721029T040000Z"}},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", "20150301T090000Z", 2,
+			[]string{"20160229T090000Z", "20200229T090000Z"}},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", "20150301T090000Z", 2,
+			[]string{}},
+		{"FREQ=DAILY;BYHOUR=9,10;BYMINUTE=0,20,40", "19970902T090000Z", 4,
+			[]string{"19970902T090000Z", "19970902T092000Z", "19970902T094000Z", "19970902T100000Z"}},
+		{"FREQ=HOURLY;INTERVAL=3;UNTIL=19970902T170000Z", "19970902T090000Z", 10,
+			[]string{"19970902T090000Z", "19970902T120000Z", "19970902T150000Z"}},
+		{"FREQ=MINUTELY;INTERVAL=15;COUNT=5", "19970902T090000Z", 10,
+			[]string{"19970902T090000Z", "19970902T091500Z", "19970902T093000Z", "19970902T094500Z", "19970902T100000Z"}},
+		{"FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10", "19970902T090000Z", 7,
+			[]string{"19970902T090000Z", "19970902T092000Z", "19970902T094000Z", "19970902T100000Z", "19970902T102000Z", "19970902T104000Z", "19970903T090000Z"}},
+		{"FREQ=SECONDLY;INTERVAL=10;COUNT=3", "19970902T090000Z", 10,
+			[]string{"19970902T090000Z", "19970902T090010Z", "19970902T090020Z"}},
+		{"FREQ=DAILY;BYMONTH=1;UNTIL=20000102", "19991231T090000Z", 10,
+			[]string{"20000101T090000Z", "20000102T090000Z"}},
+	}
+
+	for _, c := range cases {
+		found := occurrencesOf(t, c.rrule, c.dtstart, c.limit)
+		if len(found) != len(c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.rrule, c.expected, found)
+			continue
+		}
+		for i := range found {
+			if found[i] != c.expected[i] {
+				t.Errorf("%s: expected %v, got %v", c.rrule, c.expected, found)
+				break
+			}
+		}
+	}
+}
+
+func TestRecurrenceRuleInTimezone(t *testing.T) {
+	sofia, err := time.LoadLocation("Europe/Sofia")
+	if err != nil {
+		t.Skipf("No time zone data ( %s )", err)
+	}
+	rule, _ := ParseRecurrenceRule("FREQ=DAILY;COUNT=2")
+	// the wall clock time stays the same across the DST change
+	it := rule.Iterator(time.Date(2014, 10, 25, 10, 0, 0, 0, sofia))
+	it.Next()
+	second, _ := it.Next()
+	if second.Hour() != 10 || second.Day() != 26 {
+		t.Errorf("Expected 2014-10-26 10:00 in Europe/Sofia, got %s", second)
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	for _, rrule := range []string{"", "COUNT=2", "FREQ=FOO", "FREQ=DAILY;COUNT=2;UNTIL=20000101T000000Z", "FREQ=DAILY;BYDAY=XX", "FREQ=DAILY;BYMONTH=13", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;FOO=1"} {
+		if _, err := ParseRecurrenceRule(rrule); err == nil {
+			t.Errorf("Expected error for %q", rrule)
+		}
+	}
+}
+
+func TestRecurrenceRuleString(t *testing.T) {
+	rrule := "FREQ=MONTHLY;INTERVAL=2;UNTIL=20200101T000000Z;BYDAY=-1FR,MO;BYSETPOS=1;WKST=SU"
+	rule, err := ParseRecurrenceRule(rrule)
+	if err != nil {
+		t.Fatalf("Failed to parse %s ( %s )", rrule, err)
+	}
+	if rule.String() != rrule {
+		t.Errorf("Expected %s, got %s", rrule, rule.String())
+	}
+}
+
+func TestEventOccurrences(t *testing.T) {
+	start, _ := time.Parse(IcsFormat, "20190601T100000Z")
+	event := NewEvent()
+	event.SetStart(start)
+	event.SetEnd(start.Add(time.Hour))
+	event.SetRRule("FREQ=WEEKLY;INTERVAL=2;COUNT=3")
+
+	expected := []string{"20190601T100000Z", "20190615T100000Z", "20190629T100000Z"}
+	occurrences := event.Occurrences()
+	for i, exp := range expected {
+		occurrence, ok := occurrences.Next()
+		if !ok {
+			t.Fatalf("Expected %d occurrences, got %d", len(expected), i)
+		}
+		if occurrence.GetStart().Format(IcsFormat) != exp {
+			t.Errorf("Expected occurrence at %s, got %s", exp, occurrence.GetStart().Format(IcsFormat))
+		}
+		if occurrence.GetEnd().Sub(occurrence.GetStart()) != time.Hour {
+			t.Errorf("Expected occurrence to last 1h, got %s", occurrence.GetEnd().Sub(occurrence.GetStart()))
+		}
+	}
+	if _, ok := occurrences.Next(); ok {
+		t.Errorf("Expected no more occurrences")
+	}
+}
@@ -0,0 +1,239 @@
+package ics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time to the Scheduler , replace it to control the time in tests
+type Clock interface {
+	Now() time.Time
+	// returns chan that receives the time after the duration
+	After(d time.Duration) <-chan time.Time
+}
+
+// the clock of the system
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// AlarmFunc is called by the Scheduler when the alarm of an event instance is triggered
+type AlarmFunc func(event *Event, alarm *Alarm, at time.Time)
+
+// Scheduler fires the VALARMs of the events in its calendars , including the ones of the
+// instances of the repeating events. Only the alarms after the start of the scheduler are fired
+type Scheduler struct {
+	clock     Clock
+	callback  AlarmFunc
+	lookahead time.Duration
+	calendars []*Calendar
+	lock      *sync.Mutex
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// a triggered alarm of an event instance
+type scheduledAlarm struct {
+	event *Event
+	alarm *Alarm
+	at    time.Time
+}
+
+// creates new scheduler that calls the callback for every alarm
+func NewScheduler(callback AlarmFunc) *Scheduler {
+	s := new(Scheduler)
+	s.clock = realClock{}
+	s.callback = callback
+	s.lookahead = 24 * time.Hour
+	s.calendars = []*Calendar{}
+	s.lock = new(sync.Mutex)
+	s.wake = make(chan struct{}, 1)
+	return s
+}
+
+// sets the clock , the system one is used by default
+func (s *Scheduler) SetClock(clock Clock) *Scheduler {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clock = clock
+	return s
+}
+
+func (s *Scheduler) GetClock() Clock {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.clock
+}
+
+// adds calendar which alarms are fired , it can be added while the scheduler is running
+func (s *Scheduler) SetCalendar(cal *Calendar) *Scheduler {
+	return s.SetCalendars([]*Calendar{cal})
+}
+
+// adds calendars which alarms are fired , like the ones from Parser.GetCalendars
+func (s *Scheduler) SetCalendars(calendars []*Calendar) *Scheduler {
+	s.lock.Lock()
+	s.calendars = append(s.calendars, calendars...)
+	s.lock.Unlock()
+
+	// the new alarms may be before the one the scheduler waits for
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return s
+}
+
+func (s *Scheduler) GetCalendars() []*Calendar {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*Calendar{}, s.calendars...)
+}
+
+// starts firing the alarms , it does nothing when the scheduler is already running
+func (s *Scheduler) Start() *Scheduler {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stop != nil {
+		return s
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(s.clock, s.stop, s.done)
+	return s
+}
+
+// stops firing the alarms and waits for the running callback to return
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.lock.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// reports if the scheduler is started
+func (s *Scheduler) IsRunning() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stop != nil
+}
+
+func (s *Scheduler) run(clock Clock, stop, done chan struct{}) {
+	defer close(done)
+
+	from := clock.Now()
+	for {
+		now := clock.Now()
+		if now.After(from) {
+			for _, scheduled := range s.alarmsBetween(from, now) {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s.callback(scheduled.event, scheduled.alarm, scheduled.at)
+			}
+			from = now
+		}
+
+		// wake up for the next alarm , but look for new ones at least once per lookahead
+		wait := s.lookahead
+		if next := s.alarmsBetween(now, now.Add(s.lookahead)); len(next) > 0 {
+			wait = next[0].at.Sub(now)
+		}
+
+		select {
+		case <-clock.After(wait):
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// returns the alarms triggered after from and not after to , ordered by time
+func (s *Scheduler) alarmsBetween(from, to time.Time) []*scheduledAlarm {
+	scheduled := []*scheduledAlarm{}
+	for _, cal := range s.GetCalendars() {
+		for _, e := range cal.GetEvents() {
+			// the instances are returned by the occurrences of the repeating event ,
+			// the overrides are evaluated on their own , also the ones without repeating event or matching instance
+			if e.generated {
+				continue
+			}
+			scheduled = append(scheduled, eventAlarmsBetween(e, from, to)...)
+		}
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool { return scheduled[i].at.Before(scheduled[j].at) })
+	return scheduled
+}
+
+// returns the alarms of the instances of the event triggered after from and not after to
+func eventAlarmsBetween(e *Event, from, to time.Time) []*scheduledAlarm {
+	scheduled := []*scheduledAlarm{}
+	// the overrides with their own alarms are evaluated on their own
+	if len(e.GetAlarms()) == 0 {
+		return scheduled
+	}
+
+	// the relative alarms of an instance are not earlier than its start with the smallest trigger
+	var earliest time.Duration
+	for _, alarm := range e.GetAlarms() {
+		if !alarm.IsAbsolute() && alarm.GetTrigger() < earliest {
+			earliest = alarm.GetTrigger()
+		}
+	}
+	add := func(instance *Event, absolute bool) {
+		for _, alarm := range instance.GetAlarms() {
+			if alarm.IsAbsolute() != absolute {
+				continue
+			}
+			for _, at := range alarm.TriggerTimes(instance) {
+				if at.After(from) && !at.After(to) {
+					scheduled = append(scheduled, &scheduledAlarm{event: instance, alarm: alarm, at: at})
+				}
+			}
+		}
+	}
+
+	// the alarms at exact time are fired once for the whole event
+	add(e, true)
+	// the override replaces a single instance
+	if !e.GetRecurrenceID().IsZero() {
+		add(e, false)
+		return scheduled
+	}
+	occurrences := e.Occurrences()
+	for {
+		instance, ok := occurrences.Next()
+		if !ok {
+			break
+		}
+		// the instances come in the order of the times they replace , an override may be moved after to
+		if !instance.GetRecurrenceID().IsZero() {
+			if instance.GetRecurrenceID().Add(earliest).After(to) {
+				break
+			}
+			continue
+		}
+		if instance.GetStart().Add(earliest).After(to) {
+			break
+		}
+		add(instance, false)
+	}
+	return scheduled
+}
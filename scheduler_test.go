@@ -0,0 +1,197 @@
+package ics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock that moves only when the test advances it
+type fakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// moves the time and wakes the waiters which deadline has passed
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiting
+}
+
+// blocks until somebody waits for the clock
+func (c *fakeClock) waitForWaiter(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		c.lock.Lock()
+		n := len(c.waiters)
+		c.lock.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Nobody waits for the clock")
+}
+
+func TestScheduler(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(remindersCal))
+	clock := &fakeClock{now: time.Date(2019, 6, 3, 0, 0, 0, 0, time.UTC)}
+
+	lock := sync.Mutex{}
+	fired := []string{}
+	scheduler := NewScheduler(func(e *Event, a *Alarm, at time.Time) {
+		lock.Lock()
+		defer lock.Unlock()
+		fired = append(fired, e.GetImportedID()+" "+a.GetAction()+" "+at.Format(IcsFormat))
+	}).SetClock(clock).SetCalendar(cal).Start()
+	defer scheduler.Stop()
+
+	expectFired := func(expected ...string) {
+		t.Helper()
+		clock.waitForWaiter(t)
+		lock.Lock()
+		defer lock.Unlock()
+		if len(fired) != len(expected) {
+			t.Fatalf("Expected alarms %v, got %v", expected, fired)
+		}
+		for i := range expected {
+			if fired[i] != expected[i] {
+				t.Errorf("Expected alarm %s, got %s", expected[i], fired[i])
+			}
+		}
+		fired = fired[:0]
+	}
+
+	expectFired()
+	clock.Advance(8 * time.Hour)
+	expectFired("deadline AUDIO 20190603T080000Z")
+	clock.Advance(time.Minute)
+	expectFired()
+	clock.Advance(4 * time.Minute)
+	expectFired("deadline AUDIO 20190603T080500Z")
+
+	// the instances of the repeating event , the ones that are late are fired in order
+	clock.Advance(72 * time.Hour)
+	expectFired(
+		"standup DISPLAY 20190603T094500Z",
+		"standup EMAIL 20190603T103000Z",
+		"standup DISPLAY 20190604T094500Z",
+		"standup EMAIL 20190604T103000Z",
+		"standup DISPLAY 20190605T094500Z",
+		"standup EMAIL 20190605T103000Z",
+	)
+
+	scheduler.Stop()
+	if scheduler.IsRunning() {
+		t.Errorf("Expected stopped scheduler")
+	}
+}
+
+func TestSchedulerOverrides(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"DTSTART:20190601T090000Z\r\n"+
+		"DTEND:20190601T100000Z\r\n"+
+		"RRULE:FREQ=DAILY;COUNT=5\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"ACTION:DISPLAY\r\n"+
+		"TRIGGER:-PT10M\r\n"+
+		"END:VALARM\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"RECURRENCE-ID:20190602T090000Z\r\n"+
+		"DTSTART:20190620T090000Z\r\n"+
+		"DTEND:20190620T100000Z\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"ACTION:DISPLAY\r\n"+
+		"TRIGGER:-PT10M\r\n"+
+		"END:VALARM\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:daily\r\n"+
+		"RECURRENCE-ID:20190605T090000Z\r\n"+
+		"DTSTART:20190605T090000Z\r\n"+
+		"DTEND:20190605T100000Z\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"ACTION:EMAIL\r\n"+
+		"TRIGGER:-P2D\r\n"+
+		"END:VALARM\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:invitation\r\n"+
+		"RECURRENCE-ID:20190604T140000Z\r\n"+
+		"DTSTART:20190604T150000Z\r\n"+
+		"DTEND:20190604T160000Z\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"ACTION:AUDIO\r\n"+
+		"TRIGGER:-PT5M\r\n"+
+		"END:VALARM\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:quiet\r\n"+
+		"DTSTART:20190601T120000Z\r\n"+
+		"RRULE:FREQ=DAILY\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	scheduler := NewScheduler(func(e *Event, a *Alarm, at time.Time) {}).SetCalendar(cal)
+	// the moved instance does not stop the later ones , the overrides fire their own alarms
+	fired := []string{}
+	for _, alarm := range scheduler.alarmsBetween(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 6, 4, 12, 0, 0, 0, time.UTC)) {
+		fired = append(fired, alarm.event.GetImportedID()+" "+alarm.alarm.GetAction()+" "+alarm.at.Format(IcsFormat))
+	}
+	expected := []string{
+		"daily DISPLAY 20190601T085000Z",
+		"daily DISPLAY 20190603T085000Z",
+		"daily EMAIL 20190603T090000Z",
+		"daily DISPLAY 20190604T085000Z",
+	}
+	if len(fired) != len(expected) {
+		t.Fatalf("Expected alarms %v, got %v", expected, fired)
+	}
+	for i := range expected {
+		if fired[i] != expected[i] {
+			t.Errorf("Expected alarms %v, got %v", expected, fired)
+			break
+		}
+	}
+
+	fired = fired[:0]
+	for _, alarm := range scheduler.alarmsBetween(time.Date(2019, 6, 4, 12, 0, 0, 0, time.UTC), time.Date(2019, 6, 30, 0, 0, 0, 0, time.UTC)) {
+		fired = append(fired, alarm.event.GetImportedID()+" "+alarm.alarm.GetAction()+" "+alarm.at.Format(IcsFormat))
+	}
+	if len(fired) != 2 || fired[0] != "invitation AUDIO 20190604T145500Z" || fired[1] != "daily DISPLAY 20190620T085000Z" {
+		t.Errorf("Expected the alarms of the invitation and the moved instance , got %v", fired)
+	}
+}
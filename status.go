@@ -0,0 +1,47 @@
+package ics
+
+import (
+	"strings"
+)
+
+// RequestStatus is a REQUEST-STATUS , the result of a scheduling request like 2.0 Success
+type RequestStatus struct {
+	code        string
+	description string
+	data        string
+}
+
+func NewRequestStatus(code, description string) *RequestStatus {
+	return &RequestStatus{code: code, description: description}
+}
+
+// returns the hierarchical status code like 2.0 or 3.1
+func (rs *RequestStatus) GetCode() string {
+	return rs.code
+}
+
+func (rs *RequestStatus) GetDescription() string {
+	return rs.description
+}
+
+// sets the extra data like the property that caused the error
+func (rs *RequestStatus) SetData(data string) *RequestStatus {
+	rs.data = data
+	return rs
+}
+
+func (rs *RequestStatus) GetData() string {
+	return rs.data
+}
+
+// reports if the request succeeded , the codes 2.x
+func (rs *RequestStatus) IsSuccess() bool {
+	return strings.HasPrefix(rs.code, "2.")
+}
+
+func (rs *RequestStatus) String() string {
+	if rs.data != "" {
+		return rs.code + " " + rs.description + " ( " + rs.data + " )"
+	}
+	return rs.code + " " + rs.description
+}
@@ -0,0 +1,68 @@
+package ics
+
+import (
+	"strings"
+)
+
+// Text is a TEXT value with its LANGUAGE and ALTREP parameters
+type Text struct {
+	value    string
+	language string
+	altRep   string
+}
+
+// creates new text with the unescaped value
+func NewText(value string) *Text {
+	return &Text{value: value}
+}
+
+func (t *Text) GetValue() string {
+	return t.value
+}
+
+// sets the language tag like en or de-CH
+func (t *Text) SetLanguage(language string) *Text {
+	t.language = language
+	return t
+}
+
+func (t *Text) GetLanguage() string {
+	return t.language
+}
+
+// sets the uri of an alternate representation of the text , like a HTML page
+func (t *Text) SetAltRep(altRep string) *Text {
+	t.altRep = altRep
+	return t
+}
+
+func (t *Text) GetAltRep() string {
+	return t.altRep
+}
+
+func (t *Text) String() string {
+	return t.value
+}
+
+// returns the text in the language , the language tags match when they are equal or one of them
+// is the primary tag of the other , so de matches de-CH. Returns nil when there is no such text
+func textFor(texts []*Text, language string) *Text {
+	var match *Text
+	for _, t := range texts {
+		if strings.EqualFold(t.language, language) {
+			return t
+		}
+		if match == nil && t.language != "" && (primaryLanguage(t.language) == primaryLanguage(language)) {
+			match = t
+		}
+	}
+	return match
+}
+
+// returns the primary subtag of the language tag , de for de-CH
+func primaryLanguage(language string) string {
+	if i := strings.IndexByte(language, '-'); i >= 0 {
+		language = language[:i]
+	}
+	return strings.ToLower(language)
+}
@@ -0,0 +1,52 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventTextLanguages(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:party\r\n"+
+		"DTSTART:20190603T180000Z\r\n"+
+		"SUMMARY;LANGUAGE=en:Summer party\\; bring friends\r\n"+
+		"SUMMARY;LANGUAGE=de-CH:Sommerfest\r\n"+
+		"DESCRIPTION;ALTREP=\"https://example.com/party.html\":Food\\, drinks\\nand music\r\n"+
+		"LOCATION;LANGUAGE=en:Main hall\r\n"+
+		"LOCATION;LANGUAGE=fr:Grande salle\r\n"+
+		"COMMENT:Line one\\nline two\\\\\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"))
+	event, err := cal.GetEventByImportedID("party")
+	if err != nil {
+		t.Fatalf("Failed to get event by id with error %s", err)
+	}
+
+	check := func(e *Event) {
+		t.Helper()
+		if e.GetSummary() != "Summer party; bring friends" || e.GetSummaryFor("de") != "Sommerfest" || e.GetSummaryFor("EN-us") != "Summer party; bring friends" || e.GetSummaryFor("it") != e.GetSummary() {
+			t.Errorf("Unexpected summaries %v", e.GetSummaryTexts())
+		}
+		if e.GetDescription() != "Food, drinks\nand music" || len(e.GetDescriptionTexts()) != 1 || e.GetDescriptionTexts()[0].GetAltRep() != "https://example.com/party.html" {
+			t.Errorf("Unexpected description %q with %v", e.GetDescription(), e.GetDescriptionTexts())
+		}
+		if e.GetLocationFor("fr") != "Grande salle" || e.GetLocation() != "Main hall" {
+			t.Errorf("Unexpected locations %v", e.GetLocationTexts())
+		}
+		if len(e.GetComments()) != 1 || e.GetComments()[0] != "Line one\nline two\\" {
+			t.Errorf("Unexpected comments %q", e.GetComments())
+		}
+	}
+	check(event)
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !strings.Contains(string(written), "SUMMARY;LANGUAGE=de-CH:Sommerfest\r\n") || strings.Count(string(written), "SUMMARY") != 2 {
+		t.Errorf("Expected both summaries in\n%s", written)
+	}
+	rewritten, _ := loadSingleCalendar(t, written).GetEventByImportedID("party")
+	check(rewritten)
+}
@@ -0,0 +1,360 @@
+package ics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maps the Windows time zone names used by Outlook and Exchange to IANA names.
+// It is consulted when a TZID has no VTIMEZONE definition and is not an IANA name
+var defaultWindowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"UTC-11":                          "Etc/GMT+11",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time":          "America/Denver",
+	"Central America Standard Time":   "America/Guatemala",
+	"Central Standard Time":           "America/Chicago",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Eastern Standard Time":           "America/New_York",
+	"US Eastern Standard Time":        "America/Indianapolis",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Venezuela Standard Time":         "America/Caracas",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"UTC-02":                          "Etc/GMT+2",
+	"Azores Standard Time":            "Atlantic/Azores",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"Middle East Standard Time":       "Asia/Beirut",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"FLE Standard Time":               "Europe/Kiev",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Arabic Standard Time":            "Asia/Baghdad",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Russian Standard Time":           "Europe/Moscow",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Afghanistan Standard Time":       "Asia/Kabul",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"India Standard Time":             "Asia/Kolkata",
+	"Nepal Standard Time":             "Asia/Kathmandu",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"AUS Central Standard Time":       "Australia/Darwin",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"Tasmania Standard Time":          "Australia/Hobart",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"UTC+12":                          "Etc/GMT-12",
+	"Tonga Standard Time":             "Pacific/Tongatapu",
+}
+
+// returns new map with the names of all the zones , the later ones win
+func mergeZones(zones ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, names := range zones {
+		for name, ianaName := range names {
+			merged[name] = ianaName
+		}
+	}
+	return merged
+}
+
+// loads time zone by IANA name or by Windows name from the windows zones
+func loadLocation(name string, windowsZones map[string]string) (*time.Location, error) {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, nil
+	}
+	if ianaName, ok := windowsZones[name]; ok {
+		if loc, err := time.LoadLocation(ianaName); err == nil {
+			return loc, nil
+		}
+	}
+	// some producers prefix the IANA name like /mozilla.org/20050126_1/Europe/Berlin
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	for i := 1; i < len(parts)-1; i++ {
+		if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown time zone %q", name)
+}
+
+// the onsets of the VTIMEZONE rules are generated until this year
+const maxTimezoneYear = 2100
+
+// a single change of the UTC offset
+type zoneTransition struct {
+	at         time.Time
+	offsetFrom int
+	offset     int
+	isDST      bool
+	name       string
+}
+
+// parses VTIMEZONE to time.Location with the TZID as name
+func parseVTimezone(tzData *component) (*time.Location, error) {
+	tzID := tzData.value("TZID")
+	if tzID == "" {
+		return nil, errors.New("VTIMEZONE without TZID")
+	}
+
+	transitions := []zoneTransition{}
+	for _, observance := range tzData.components {
+		if observance.name != "STANDARD" && observance.name != "DAYLIGHT" {
+			continue
+		}
+		observanceTransitions, err := parseObservance(observance)
+		if err != nil {
+			return nil, fmt.Errorf("VTIMEZONE %s: %s", tzID, err)
+		}
+		transitions = append(transitions, observanceTransitions...)
+	}
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("VTIMEZONE %s has no STANDARD or DAYLIGHT", tzID)
+	}
+	sort.SliceStable(transitions, func(i, j int) bool { return transitions[i].at.Before(transitions[j].at) })
+
+	return newLocation(tzID, transitions)
+}
+
+// returns the transitions of a STANDARD or DAYLIGHT rule
+func parseObservance(observance *component) ([]zoneTransition, error) {
+	offsetFrom, err := parseUTCOffset(observance.value("TZOFFSETFROM"))
+	if err != nil {
+		return nil, err
+	}
+	offsetTo, err := parseUTCOffset(observance.value("TZOFFSETTO"))
+	if err != nil {
+		return nil, err
+	}
+	name := observance.value("TZNAME")
+	if name == "" {
+		name = formatUTCOffset(offsetTo)
+	}
+
+	// the onset is local time before the change , it is kept as floating time in UTC
+	start, err := time.Parse(IcsFormat, strings.TrimSuffix(observance.value("DTSTART"), "Z")+"Z")
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s DTSTART %q", observance.name, observance.value("DTSTART"))
+	}
+	onsets := []time.Time{start}
+
+	if rrule := observance.value("RRULE"); rrule != "" {
+		rule, err := ParseRecurrenceRule(rrule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s RRULE: %s", observance.name, err)
+		}
+		it := rule.Iterator(start)
+		for {
+			onset, ok := it.Next()
+			if !ok || onset.Year() > maxTimezoneYear {
+				break
+			}
+			if !onset.Equal(start) {
+				onsets = append(onsets, onset)
+			}
+		}
+	}
+	for _, prop := range observance.propertiesByName("RDATE") {
+		for _, value := range strings.Split(prop.value, ",") {
+			onset, err := time.Parse(IcsFormat, strings.TrimSuffix(value, "Z")+"Z")
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s RDATE %q", observance.name, value)
+			}
+			onsets = append(onsets, onset)
+		}
+	}
+
+	transitions := make([]zoneTransition, len(onsets))
+	for i, onset := range onsets {
+		transitions[i] = zoneTransition{
+			at:         onset.Add(-time.Duration(offsetFrom) * time.Second),
+			offsetFrom: offsetFrom,
+			offset:     offsetTo,
+			isDST:      observance.name == "DAYLIGHT",
+			name:       name,
+		}
+	}
+	return transitions, nil
+}
+
+// parses UTC offset like +0200 , -0530 or +013045 to seconds
+func parseUTCOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	hours, errH := strconv.Atoi(value[1:3])
+	minutes, errM := strconv.Atoi(value[3:5])
+	seconds := 0
+	var errS error
+	if len(value) == 7 {
+		seconds, errS = strconv.Atoi(value[5:7])
+	}
+	if errH != nil || errM != nil || errS != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	offset := hours*3600 + minutes*60 + seconds
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// formats UTC offset in seconds like +0200
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	if offset%60 != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, offset/3600, offset/60%60, offset%60)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset/60%60)
+}
+
+// builds time.Location from the sorted transitions by encoding them as TZif data
+func newLocation(name string, transitions []zoneTransition) (*time.Location, error) {
+	type zoneType struct {
+		offset int
+		isDST  bool
+		name   string
+	}
+	types := []zoneType{}
+	typeIndex := func(zt zoneType) int {
+		for i, t := range types {
+			if t == zt {
+				return i
+			}
+		}
+		types = append(types, zt)
+		return len(types) - 1
+	}
+
+	// the zone before the first transition is the first type , it takes the name
+	// of a rule with the same offset if there is such
+	first := zoneType{offset: transitions[0].offsetFrom, name: formatUTCOffset(transitions[0].offsetFrom)}
+	for _, t := range transitions {
+		if t.offset == first.offset && !t.isDST {
+			first.name = t.name
+			break
+		}
+	}
+	last := typeIndex(first)
+
+	times := []int64{}
+	indexes := []byte{}
+	for _, t := range transitions {
+		i := typeIndex(zoneType{t.offset, t.isDST, t.name})
+		if i == last {
+			continue
+		}
+		times = append(times, t.at.Unix())
+		indexes = append(indexes, byte(i))
+		last = i
+	}
+	if len(types) > 255 {
+		return nil, fmt.Errorf("time zone %s has too many offsets", name)
+	}
+
+	chars := []byte{}
+	nameIndex := make([]int, len(types))
+	for i, zt := range types {
+		nameIndex[i] = len(chars)
+		chars = append(chars, zt.name...)
+		chars = append(chars, 0)
+	}
+
+	// TZif version 2 : an empty version 1 block followed by the 64-bit data
+	buf := new(bytes.Buffer)
+	header := func(timeCount, typeCount, charCount int) {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		for _, n := range []int{0, 0, 0, timeCount, typeCount, charCount} {
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+	}
+	writeType := func(zt zoneType, index int) {
+		binary.Write(buf, binary.BigEndian, int32(zt.offset))
+		if zt.isDST {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		buf.WriteByte(byte(index))
+	}
+
+	header(0, 1, len(types[0].name)+1)
+	writeType(types[0], 0)
+	buf.WriteString(types[0].name)
+	buf.WriteByte(0)
+
+	header(len(times), len(types), len(chars))
+	for _, t := range times {
+		binary.Write(buf, binary.BigEndian, t)
+	}
+	buf.Write(indexes)
+	for i, zt := range types {
+		writeType(zt, nameIndex[i])
+	}
+	buf.Write(chars)
+	buf.WriteString("\n\n")
+
+	return time.LoadLocationFromTZData(name, buf.Bytes())
+}
+
+// parses a VTIMEZONE component and adds it to the time zones of the calendar
+func (p *Parser) parseTimezone(cal *Calendar, tzData *component) {
+	loc, err := parseVTimezone(tzData)
+	if err != nil {
+		p.addError(cal, newParseError(cal.GetUrl(), tzData.line, tzData.name, "", err))
+		return
+	}
+	cal.SetTimezoneByID(tzData.value("TZID"), loc)
+	cal.setTimezoneDef(tzData.value("TZID"), tzData)
+}
+
+// returns the location for the TZID of the property. when the TZID is unknown the error is
+// recorded once and the time zone of the calendar is used instead
+func (p *Parser) timezoneFor(cal *Calendar, comp *component, prop *contentLine) *time.Location {
+	tzID := prop.param("TZID")
+	loc, err := cal.GetTimezoneByID(tzID)
+	if err != nil {
+		p.addError(cal, newParseError(cal.GetUrl(), prop.line, comp.name, prop.name, err))
+		loc = cal.location()
+		cal.SetTimezoneByID(tzID, loc)
+	}
+	return loc
+}
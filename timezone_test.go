@@ -0,0 +1,111 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const customTimezoneCal = `BEGIN:VCALENDAR
+X-WR-TIMEZONE:Europe/Sofia
+BEGIN:VTIMEZONE
+TZID:My Zone
+BEGIN:STANDARD
+DTSTART:19701025T030000
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+TZOFFSETFROM:+0300
+TZOFFSETTO:+0100
+TZNAME:MST
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:19700329T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0300
+TZNAME:MDT
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:summer
+DTSTART;TZID=My Zone:20190701T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:winter
+DTSTART;TZID=My Zone:20190101T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:windows
+DTSTART;TZID=Pacific Standard Time:20190101T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:floating
+DTSTART:20190101T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:unknown
+DTSTART;TZID=Nowhere:20190101T100000
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestEventTimesInTimezones(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Sofia"); err != nil {
+		t.Skipf("No time zone data ( %s )", err)
+	}
+	parser := New()
+	if err := parser.LoadReader(strings.NewReader(customTimezoneCal)); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	calendars, _ := parser.GetCalendars()
+	if len(calendars) != 1 {
+		t.Fatalf("Expected 1 calendar, found %d calendars", len(calendars))
+	}
+	cal := calendars[0]
+
+	expected := map[string]string{
+		"summer":   "2019-07-01T07:00:00Z",
+		"winter":   "2019-01-01T09:00:00Z",
+		"windows":  "2019-01-01T18:00:00Z",
+		"floating": "2019-01-01T08:00:00Z",
+		"unknown":  "2019-01-01T08:00:00Z",
+	}
+	for id, exp := range expected {
+		event, err := cal.GetEventByImportedID(id)
+		if err != nil {
+			t.Errorf("Failed to get event %s ( %s )", id, err)
+			continue
+		}
+		if got := event.GetStart().UTC().Format(time.RFC3339); got != exp {
+			t.Errorf("Expected event %s to start at %s, got %s", id, exp, got)
+		}
+	}
+
+	summer, _ := cal.GetEventByImportedID("summer")
+	if name, _ := summer.GetStart().Zone(); name != "MDT" {
+		t.Errorf("Expected zone name MDT, got %s", name)
+	}
+
+	errs, _ := parser.GetErrors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "Nowhere") {
+		t.Errorf("Expected 1 error for the unknown time zone, got %v", errs)
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skipf("No time zone data ( %s )", err)
+	}
+	for _, name := range []string{"Europe/Berlin", "W. Europe Standard Time", "/mozilla.org/20050126_1/Europe/Berlin"} {
+		loc, err := loadLocation(name, defaultWindowsZones)
+		if err != nil {
+			t.Errorf("Failed to load %s ( %s )", name, err)
+			continue
+		}
+		if _, offset := time.Date(2019, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != 3600 {
+			t.Errorf("Expected offset 3600 for %s, got %d", name, offset)
+		}
+	}
+	if _, err := loadLocation("Nowhere", defaultWindowsZones); err == nil {
+		t.Errorf("Expected error for unknown time zone")
+	}
+}
@@ -0,0 +1,293 @@
+package ics
+
+import (
+	"crypto/md5"
+	"fmt"
+	"time"
+)
+
+// Todo is a VTODO , a task with optional start , due date and progress
+type Todo struct {
+	start           time.Time
+	due             time.Time
+	startTZID       string
+	dueTZID         string
+	completed       time.Time
+	created         time.Time
+	modified        time.Time
+	importedId      string
+	id              string
+	status          string
+	summary         string
+	description     string
+	location        string
+	class           string
+	sequence        int
+	priority        int
+	percentComplete int
+	relatedTo       []string
+	attendees       []*Attendee
+	organizer       *Attendee
+	wholeDay        bool
+	inCalendar      *Calendar
+	propertySet
+}
+
+func NewTodo() *Todo {
+	t := new(Todo)
+	t.attendees = []*Attendee{}
+	t.relatedTo = []string{}
+	t.properties = []*Property{}
+	return t
+}
+
+func (t *Todo) SetStart(start time.Time) *Todo {
+	t.start = start
+	return t
+}
+
+func (t *Todo) GetStart() time.Time {
+	return t.start
+}
+
+func (t *Todo) SetDue(due time.Time) *Todo {
+	t.due = due
+	return t
+}
+
+func (t *Todo) GetDue() time.Time {
+	return t.due
+}
+
+func (t *Todo) SetStartTZID(tzid string) *Todo {
+	t.startTZID = tzid
+	return t
+}
+
+func (t *Todo) GetStartTZID() string {
+	return t.startTZID
+}
+
+func (t *Todo) SetDueTZID(tzid string) *Todo {
+	t.dueTZID = tzid
+	return t
+}
+
+func (t *Todo) GetDueTZID() string {
+	return t.dueTZID
+}
+
+// sets the time when the todo was completed
+func (t *Todo) SetCompleted(completed time.Time) *Todo {
+	t.completed = completed
+	return t
+}
+
+func (t *Todo) GetCompleted() time.Time {
+	return t.completed
+}
+
+func (t *Todo) SetCreated(created time.Time) *Todo {
+	t.created = created
+	return t
+}
+
+func (t *Todo) GetCreated() time.Time {
+	return t.created
+}
+
+func (t *Todo) SetLastModified(modified time.Time) *Todo {
+	t.modified = modified
+	return t
+}
+
+func (t *Todo) GetLastModified() time.Time {
+	return t.modified
+}
+
+func (t *Todo) SetImportedID(id string) *Todo {
+	t.importedId = id
+	return t
+}
+
+func (t *Todo) GetImportedID() string {
+	return t.importedId
+}
+
+func (t *Todo) SetID(id string) *Todo {
+	t.id = id
+	return t
+}
+
+func (t *Todo) GetID() string {
+	return t.id
+}
+
+// sets the status like NEEDS-ACTION , COMPLETED , IN-PROCESS or CANCELLED
+func (t *Todo) SetStatus(status string) *Todo {
+	t.status = status
+	return t
+}
+
+func (t *Todo) GetStatus() string {
+	return t.status
+}
+
+func (t *Todo) SetSummary(summary string) *Todo {
+	t.summary = summary
+	return t
+}
+
+func (t *Todo) GetSummary() string {
+	return t.summary
+}
+
+func (t *Todo) SetDescription(description string) *Todo {
+	t.description = description
+	return t
+}
+
+func (t *Todo) GetDescription() string {
+	return t.description
+}
+
+func (t *Todo) SetLocation(location string) *Todo {
+	t.location = location
+	return t
+}
+
+func (t *Todo) GetLocation() string {
+	return t.location
+}
+
+func (t *Todo) SetClass(class string) *Todo {
+	t.class = class
+	return t
+}
+
+func (t *Todo) GetClass() string {
+	return t.class
+}
+
+func (t *Todo) SetSequence(sq int) *Todo {
+	t.sequence = sq
+	return t
+}
+
+func (t *Todo) GetSequence() int {
+	return t.sequence
+}
+
+// sets the priority from 1 ( the highest ) to 9 ( the lowest ) , 0 is undefined
+func (t *Todo) SetPriority(priority int) *Todo {
+	t.priority = priority
+	return t
+}
+
+func (t *Todo) GetPriority() int {
+	return t.priority
+}
+
+// sets the percent of the todo that is completed , from 0 to 100
+func (t *Todo) SetPercentComplete(percent int) *Todo {
+	t.percentComplete = percent
+	return t
+}
+
+func (t *Todo) GetPercentComplete() int {
+	return t.percentComplete
+}
+
+// adds the UID of a related component , like the parent task
+func (t *Todo) SetRelatedTo(uid string) *Todo {
+	t.relatedTo = append(t.relatedTo, uid)
+	return t
+}
+
+func (t *Todo) SetRelatedToList(uids []string) *Todo {
+	t.relatedTo = uids
+	return t
+}
+
+// returns the UIDs of the related components
+func (t *Todo) GetRelatedTo() []string {
+	return t.relatedTo
+}
+
+func (t *Todo) SetAttendee(a *Attendee) *Todo {
+	t.attendees = append(t.attendees, a)
+	return t
+}
+
+func (t *Todo) SetAttendees(attendees []*Attendee) *Todo {
+	t.attendees = append(t.attendees, attendees...)
+	return t
+}
+
+func (t *Todo) GetAttendees() []*Attendee {
+	return t.attendees
+}
+
+func (t *Todo) SetOrganizer(a *Attendee) *Todo {
+	t.organizer = a
+	return t
+}
+
+func (t *Todo) GetOrganizer() *Attendee {
+	return t.organizer
+}
+
+// sets if the start and due are dates without time
+func (t *Todo) SetWholeDay(wholeDay bool) *Todo {
+	t.wholeDay = wholeDay
+	return t
+}
+
+func (t *Todo) IsWholeDay() bool {
+	return t.wholeDay
+}
+
+// reports if the todo is completed
+func (t *Todo) IsCompleted() bool {
+	return t.status == "COMPLETED" || !t.completed.IsZero()
+}
+
+func (t *Todo) SetCalendar(cal *Calendar) *Todo {
+	t.inCalendar = cal
+	return t
+}
+
+func (t *Todo) GetCalendar() *Calendar {
+	return t.inCalendar
+}
+
+// generates an unique id for the todo
+func (t *Todo) GenerateTodoId() string {
+	var toBeHashed string
+	if t.GetImportedID() != "" {
+		toBeHashed = fmt.Sprintf("%s%s%s", t.GetStart(), t.GetDue(), t.GetImportedID())
+	} else {
+		toBeHashed = fmt.Sprintf("%s%s%s%s", t.GetStart(), t.GetDue(), t.GetSummary(), t.GetDescription())
+	}
+	return fmt.Sprintf("%x", md5.Sum(stringToByte(toBeHashed)))
+}
+
+// adds a raw property , the unknown and X- properties are written back as they are
+func (t *Todo) SetProperty(p *Property) *Todo {
+	t.appendProperty(p)
+	return t
+}
+
+// replaces all the raw properties
+func (t *Todo) SetProperties(properties []*Property) *Todo {
+	t.replaceProperties(properties)
+	return t
+}
+
+func (t *Todo) String() string {
+	due := "no due date"
+	if !t.GetDue().IsZero() {
+		due = "due " + t.GetDue().Format(YmdHis)
+	}
+	return fmt.Sprintf("Todo(%s) %s , %s , %d%% completed", t.GetStatus(), t.GetSummary(), due, t.GetPercentComplete())
+}
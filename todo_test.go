@@ -0,0 +1,107 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const taskList = "BEGIN:VCALENDAR\r\n" +
+	"X-WR-CALNAME:Tasks\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:release\r\n" +
+	"SUMMARY:Ship the release\r\n" +
+	"DTSTART:20190601T090000Z\r\n" +
+	"DUE:20190610T170000Z\r\n" +
+	"PRIORITY:1\r\n" +
+	"PERCENT-COMPLETE:40\r\n" +
+	"STATUS:IN-PROCESS\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:notes\r\n" +
+	"SUMMARY:Write the release notes\r\n" +
+	"DUE;VALUE=DATE:20190608\r\n" +
+	"COMPLETED:20190607T120000Z\r\n" +
+	"STATUS:COMPLETED\r\n" +
+	"PERCENT-COMPLETE:100\r\n" +
+	"RELATED-TO:release\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:party\r\n" +
+	"DTSTART:20190611T170000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseTodos(t *testing.T) {
+	parser := New()
+	parser.Load(taskList)
+
+	calendars, _ := parser.GetCalendars()
+	if len(calendars) != 1 {
+		t.Fatalf("Expected 1 calendar, found %d calendars", len(calendars))
+	}
+	cal := calendars[0]
+	if len(cal.GetTodos()) != 2 || len(cal.GetEvents()) != 1 {
+		t.Fatalf("Expected 2 todos and 1 event, got %d and %d", len(cal.GetTodos()), len(cal.GetEvents()))
+	}
+
+	release, err := cal.GetTodoByImportedID("release")
+	if err != nil {
+		t.Fatalf("Failed to get todo by id with error %s", err)
+	}
+	due, _ := time.Parse(IcsFormat, "20190610T170000Z")
+	if !release.GetDue().Equal(due) || release.GetPriority() != 1 || release.GetPercentComplete() != 40 || release.GetStatus() != "IN-PROCESS" || release.IsCompleted() {
+		t.Errorf("Unexpected todo %s", release)
+	}
+	if byID, _ := cal.GetTodoByID(release.GetID()); byID != release {
+		t.Errorf("Expected the same todo by id")
+	}
+
+	notes, err := cal.GetTodoByImportedID("notes")
+	if err != nil {
+		t.Fatalf("Failed to get todo by id with error %s", err)
+	}
+	if !notes.IsCompleted() || !notes.IsWholeDay() || len(notes.GetRelatedTo()) != 1 || notes.GetRelatedTo()[0] != "release" {
+		t.Errorf("Unexpected todo %s related to %v", notes, notes.GetRelatedTo())
+	}
+	if _, err := cal.GetTodoByImportedID("party"); err == nil {
+		t.Errorf("Expected no todo for the event")
+	}
+
+	received := []string{}
+	for len(received) < 2 {
+		select {
+		case todo := <-parser.GetTodoOutputChan():
+			received = append(received, todo.GetImportedID())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 2 todos in the output chan, got %v", received)
+		}
+	}
+	if received[0] != "release" || received[1] != "notes" {
+		t.Errorf("Unexpected todos %v", received)
+	}
+}
+
+func TestWriteTodos(t *testing.T) {
+	parser := New()
+	parser.Load(taskList)
+	calendars, _ := parser.GetCalendars()
+
+	written, err := calendars[0].MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !strings.Contains(string(written), "DUE;VALUE=DATE:20190608\r\n") {
+		t.Errorf("Expected the whole day due date in\n%s", written)
+	}
+	cal := loadSingleCalendar(t, written)
+	if len(cal.GetTodos()) != 2 {
+		t.Fatalf("Expected 2 todos, got %d", len(cal.GetTodos()))
+	}
+	for i, todo := range cal.GetTodos() {
+		expected := calendars[0].GetTodos()[i]
+		if todo.String() != expected.String() || !todo.GetCompleted().Equal(expected.GetCompleted()) || len(todo.GetRelatedTo()) != len(expected.GetRelatedTo()) {
+			t.Errorf("Expected %s, got %s", expected, todo)
+		}
+	}
+}
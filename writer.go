@@ -0,0 +1,839 @@
+package ics
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// the PRODID of the written calendars
+const prodID = "-//PuloV//ics-golang//EN"
+
+// max length of a written line in octets , without the line break
+const maxLineOctets = 75
+
+// the properties that are written from the fields of the components ,
+// the other raw properties are written as they were read
+var writtenProperties = map[string][]string{
+	"VCALENDAR": {"VERSION", "PRODID", "METHOD", "CALSCALE", "NAME", "X-WR-CALNAME", "DESCRIPTION", "X-WR-CALDESC", "X-WR-TIMEZONE",
+		"REFRESH-INTERVAL", "X-PUBLISHED-TTL", "COLOR", "IMAGE", "SOURCE"},
+	"VEVENT": {"UID", "DTSTAMP", "DTSTART", "DTEND", "DURATION", "RECURRENCE-ID", "RRULE", "RDATE", "EXDATE", "CREATED", "LAST-MODIFIED",
+		"SEQUENCE", "STATUS", "CLASS", "TRANSP", "SUMMARY", "DESCRIPTION", "LOCATION", "GEO", "ORGANIZER", "ATTENDEE", "COLOR", "IMAGE",
+		"CONFERENCE", "URL", "PRIORITY", "CATEGORIES", "COMMENT", "CONTACT", "RESOURCES", "ATTACH", "RELATED-TO", "REQUEST-STATUS"},
+	"VTODO": {"UID", "DTSTAMP", "DTSTART", "DUE", "DURATION", "COMPLETED", "CREATED", "LAST-MODIFIED", "SEQUENCE", "STATUS", "CLASS",
+		"PRIORITY", "PERCENT-COMPLETE", "SUMMARY", "DESCRIPTION", "LOCATION", "RELATED-TO", "ORGANIZER", "ATTENDEE"},
+	"VJOURNAL": {"UID", "DTSTAMP", "DTSTART", "CREATED", "LAST-MODIFIED", "SEQUENCE", "STATUS", "CLASS", "SUMMARY", "DESCRIPTION",
+		"ATTACH", "CATEGORIES", "ORGANIZER"},
+	"VALARM":    {"ACTION", "TRIGGER", "DURATION", "REPEAT", "SUMMARY", "DESCRIPTION", "ATTENDEE"},
+	"VFREEBUSY": {"UID", "DTSTAMP", "DTSTART", "DTEND", "ORGANIZER", "ATTENDEE", "FREEBUSY"},
+}
+
+// writes the calendar as RFC 5545 text. The events generated from repeating events
+// by the recurrence expansion of the parser are not written , their RRULE is. Implements io.WriterTo
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	iw := &icsWriter{w: w}
+	iw.component(c.toComponent())
+	return iw.n, iw.err
+}
+
+// returns the calendar as RFC 5545 text
+func (c *Calendar) MarshalICS() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	_, err := c.WriteTo(buf)
+	return buf.Bytes(), err
+}
+
+// writes the free/busy as RFC 5545 calendar with the VFREEBUSY only. Implements io.WriterTo
+func (fb *FreeBusy) WriteTo(w io.Writer) (int64, error) {
+	cal := NewCalendar()
+	cal.freeBusies = append(cal.freeBusies, fb)
+	return cal.WriteTo(w)
+}
+
+// returns the free/busy as RFC 5545 calendar
+func (fb *FreeBusy) MarshalICS() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	_, err := fb.WriteTo(buf)
+	return buf.Bytes(), err
+}
+
+// builds the VCALENDAR component of the calendar
+func (c *Calendar) toComponent() *component {
+	cal := &component{name: "VCALENDAR"}
+	version := "2.0"
+	if c.GetVersion() != 0 {
+		version = strconv.FormatFloat(c.GetVersion(), 'f', 1, 64)
+	}
+	cal.addProperty("VERSION", version)
+	// the PRODID of the producer is kept , the library is the producer of the new calendars
+	producer := prodID
+	if c.GetProdID() != "" {
+		producer = c.GetProdID()
+	}
+	cal.addProperty("PRODID", producer)
+	if c.GetCalScale() != "" {
+		cal.addProperty("CALSCALE", c.GetCalScale())
+	}
+	if c.GetMethod() != "" {
+		cal.addProperty("METHOD", c.GetMethod())
+	}
+	// the RFC 7986 properties and the older X- ones that more clients understand
+	if c.GetName() != "" {
+		cal.addProperty("NAME", escapeText(c.GetName()))
+		cal.addProperty("X-WR-CALNAME", escapeText(c.GetName()))
+	}
+	if c.GetDesc() != "" {
+		cal.addProperty("DESCRIPTION", escapeText(c.GetDesc()))
+		cal.addProperty("X-WR-CALDESC", escapeText(c.GetDesc()))
+	}
+	if c.GetRefreshInterval() > 0 {
+		cal.addProperty("REFRESH-INTERVAL", formatDuration(c.GetRefreshInterval())).addParam("VALUE", "DURATION")
+		cal.addProperty("X-PUBLISHED-TTL", formatDuration(c.GetRefreshInterval()))
+	}
+	if c.GetColor() != "" {
+		cal.addProperty("COLOR", c.GetColor())
+	}
+	for _, image := range c.GetImages() {
+		cal.properties = append(cal.properties, imageProperty(image))
+	}
+	if c.GetSource() != "" {
+		cal.addProperty("SOURCE", c.GetSource()).addParam("VALUE", "URI")
+	}
+	if tz := c.location().String(); tz != "" && tz != "UTC" && tz != "Local" {
+		cal.addProperty("X-WR-TIMEZONE", tz)
+	}
+	cal.addRawProperties(c.GetRawProperties())
+
+	events := []*Event{}
+	for _, event := range c.GetEvents() {
+		if !event.generated {
+			events = append(events, event)
+		}
+	}
+	todos, journals := c.GetTodos(), c.GetJournals()
+
+	// every used TZID needs its VTIMEZONE
+	usedTZIDs := []string{}
+	for _, event := range events {
+		usedTZIDs = append(usedTZIDs, event.GetStartTZID(), event.GetEndTZID())
+	}
+	for _, todo := range todos {
+		usedTZIDs = append(usedTZIDs, todo.GetStartTZID(), todo.GetDueTZID())
+	}
+	for _, journal := range journals {
+		usedTZIDs = append(usedTZIDs, journal.GetStartTZID())
+	}
+	tzIDs := []string{}
+	for _, tzID := range usedTZIDs {
+		if tzID != "" && !containsString(tzIDs, tzID) {
+			tzIDs = append(tzIDs, tzID)
+		}
+	}
+	sort.Strings(tzIDs)
+	for _, tzID := range tzIDs {
+		cal.components = append(cal.components, c.vTimezone(tzID, events))
+	}
+
+	for _, event := range events {
+		cal.components = append(cal.components, c.eventComponent(event))
+	}
+	for _, todo := range todos {
+		cal.components = append(cal.components, c.todoComponent(todo))
+	}
+	for _, journal := range journals {
+		cal.components = append(cal.components, c.journalComponent(journal))
+	}
+	for _, fb := range c.GetFreeBusies() {
+		cal.components = append(cal.components, freeBusyComponent(fb))
+	}
+	return cal
+}
+
+// returns the VTIMEZONE for the TZID , the parsed definition when there is such
+func (c *Calendar) vTimezone(tzID string, events []*Event) *component {
+	if def := c.getTimezoneDef(tzID); def != nil {
+		return def
+	}
+	loc, err := c.GetTimezoneByID(tzID)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	// the definition covers the years of the events
+	var from, to time.Time
+	for _, event := range events {
+		for _, t := range []time.Time{event.GetStart(), event.GetEnd()} {
+			if t.IsZero() {
+				continue
+			}
+			if from.IsZero() || t.Before(from) {
+				from = t
+			}
+			if to.IsZero() || t.After(to) {
+				to = t
+			}
+		}
+	}
+	if from.IsZero() {
+		from, to = time.Now(), time.Now()
+	}
+	return newVTimezone(tzID, loc, time.Date(from.Year(), 1, 1, 0, 0, 0, 0, loc), time.Date(to.Year()+1, 12, 31, 0, 0, 0, 0, loc))
+}
+
+// builds VTIMEZONE with an observance for each offset change of the location between the times
+func newVTimezone(tzID string, loc *time.Location, from, to time.Time) *component {
+	tz := &component{name: "VTIMEZONE"}
+	tz.addProperty("TZID", tzID)
+
+	observance := func(at time.Time, offsetFrom int) {
+		name, offset := at.In(loc).Zone()
+		kind := "STANDARD"
+		if at.In(loc).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		o := &component{name: kind}
+		// the onset is in the local time before the change
+		o.addProperty("DTSTART", at.UTC().Add(time.Duration(offsetFrom)*time.Second).Format(icsFormatLocal))
+		o.addProperty("TZOFFSETFROM", formatUTCOffset(offsetFrom))
+		o.addProperty("TZOFFSETTO", formatUTCOffset(offset))
+		o.addProperty("TZNAME", name)
+		tz.components = append(tz.components, o)
+	}
+
+	_, offset := from.In(loc).Zone()
+	observance(from, offset)
+	for t := from; ; {
+		_, end := t.In(loc).ZoneBounds()
+		if end.IsZero() || end.After(to) {
+			break
+		}
+		_, offset = t.In(loc).Zone()
+		observance(end, offset)
+		t = end
+	}
+	return tz
+}
+
+// builds the VEVENT component of the event
+func (c *Calendar) eventComponent(e *Event) *component {
+	ev := &component{name: "VEVENT"}
+
+	uid := e.GetImportedID()
+	if uid == "" {
+		uid = e.GetID()
+	}
+	if uid == "" {
+		uid = e.GenerateEventId()
+	}
+	ev.addProperty("UID", uid)
+
+	stamp := e.GetDTStamp()
+	if stamp.IsZero() {
+		stamp = e.GetLastModified()
+	}
+	if stamp.IsZero() {
+		stamp = e.GetCreated()
+	}
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	ev.addProperty("DTSTAMP", stamp.UTC().Format(IcsFormat))
+
+	if !e.GetStart().IsZero() {
+		ev.properties = append(ev.properties, c.timeProperty("DTSTART", e.GetStart(), e.GetStartTZID(), e.IsWholeDay()))
+	}
+	if !e.GetEnd().IsZero() {
+		ev.properties = append(ev.properties, c.timeProperty("DTEND", e.GetEnd(), e.GetEndTZID(), e.IsWholeDay()))
+	}
+	if !e.GetRecurrenceID().IsZero() {
+		ev.properties = append(ev.properties, c.timeProperty("RECURRENCE-ID", e.GetRecurrenceID(), e.GetStartTZID(), e.IsWholeDay()))
+	}
+	if e.GetRRule() != "" {
+		ev.addProperty("RRULE", e.GetRRule())
+	}
+	for _, rDate := range e.GetRDates() {
+		ev.properties = append(ev.properties, c.timeProperty("RDATE", rDate, e.GetStartTZID(), e.IsWholeDay()))
+	}
+	for _, exDate := range e.GetExDates() {
+		ev.properties = append(ev.properties, c.timeProperty("EXDATE", exDate, e.GetStartTZID(), e.IsWholeDay()))
+	}
+	if !e.GetCreated().IsZero() {
+		ev.addProperty("CREATED", e.GetCreated().UTC().Format(IcsFormat))
+	}
+	if !e.GetLastModified().IsZero() {
+		ev.addProperty("LAST-MODIFIED", e.GetLastModified().UTC().Format(IcsFormat))
+	}
+	if e.GetSequence() != 0 {
+		ev.addProperty("SEQUENCE", strconv.Itoa(e.GetSequence()))
+	}
+	if e.GetStatus() != "" {
+		ev.addProperty("STATUS", e.GetStatus())
+	}
+	if e.GetClass() != "" {
+		ev.addProperty("CLASS", e.GetClass())
+	}
+	if e.GetTransp() != "" {
+		ev.addProperty("TRANSP", e.GetTransp())
+	}
+	ev.addTextProperties("SUMMARY", e.GetSummary(), e.GetSummaryTexts())
+	ev.addTextProperties("DESCRIPTION", e.GetDescription(), e.GetDescriptionTexts())
+	ev.addTextProperties("LOCATION", e.GetLocation(), e.GetLocationTexts())
+	if geo := e.GetGeo(); geo != nil {
+		ev.addProperty("GEO", geo.latStr+";"+geo.longStr)
+	}
+	if organizer := e.GetOrganizer(); organizer != nil {
+		ev.properties = append(ev.properties, attendeeProperty("ORGANIZER", organizer))
+	}
+	for _, attendee := range e.GetAttendees() {
+		ev.properties = append(ev.properties, attendeeProperty("ATTENDEE", attendee))
+	}
+	if e.GetURL() != "" {
+		ev.addProperty("URL", e.GetURL())
+	}
+	if e.GetPriority() != 0 {
+		ev.addProperty("PRIORITY", strconv.Itoa(e.GetPriority()))
+	}
+	if len(e.GetCategories()) > 0 {
+		ev.addProperty("CATEGORIES", joinTextList(e.GetCategories()))
+	}
+	for _, comment := range e.GetComments() {
+		ev.addProperty("COMMENT", escapeText(comment))
+	}
+	for _, contact := range e.GetContacts() {
+		ev.addProperty("CONTACT", escapeText(contact))
+	}
+	if len(e.GetResources()) > 0 {
+		ev.addProperty("RESOURCES", joinTextList(e.GetResources()))
+	}
+	for _, attachment := range e.GetAttachments() {
+		// the content of the streamed attachments is not kept
+		if attachment.IsStreamed() {
+			continue
+		}
+		ev.properties = append(ev.properties, attachmentProperty(attachment))
+	}
+	for _, uid := range e.GetRelatedTo() {
+		ev.addProperty("RELATED-TO", uid)
+	}
+	for _, rs := range e.GetRequestStatuses() {
+		value := rs.GetCode() + ";" + escapeText(rs.GetDescription())
+		if rs.GetData() != "" {
+			value += ";" + escapeText(rs.GetData())
+		}
+		ev.addProperty("REQUEST-STATUS", value)
+	}
+	if e.GetColor() != "" {
+		ev.addProperty("COLOR", e.GetColor())
+	}
+	for _, image := range e.GetImages() {
+		ev.properties = append(ev.properties, imageProperty(image))
+	}
+	for _, conference := range e.GetConferences() {
+		if !conference.IsDetected() {
+			ev.properties = append(ev.properties, conferenceProperty(conference))
+		}
+	}
+	ev.addRawProperties(e.GetRawProperties())
+	for _, alarm := range e.GetAlarms() {
+		ev.components = append(ev.components, alarmComponent(alarm))
+	}
+	return ev
+}
+
+// builds the VALARM component of the alarm
+func alarmComponent(a *Alarm) *component {
+	alarm := &component{name: "VALARM"}
+	alarm.addProperty("ACTION", a.GetAction())
+	if a.IsAbsolute() {
+		alarm.addProperty("TRIGGER", a.GetTriggerTime().UTC().Format(IcsFormat)).addParam("VALUE", "DATE-TIME")
+	} else {
+		trigger := alarm.addProperty("TRIGGER", formatDuration(a.GetTrigger()))
+		if a.GetTriggerRelated() == "END" {
+			trigger.addParam("RELATED", "END")
+		}
+	}
+	if a.GetRepeat() != 0 && a.GetDuration() != 0 {
+		alarm.addProperty("DURATION", formatDuration(a.GetDuration()))
+		alarm.addProperty("REPEAT", strconv.Itoa(a.GetRepeat()))
+	}
+	if a.GetSummary() != "" {
+		alarm.addProperty("SUMMARY", escapeText(a.GetSummary()))
+	}
+	if a.GetDescription() != "" {
+		alarm.addProperty("DESCRIPTION", escapeText(a.GetDescription()))
+	}
+	for _, attendee := range a.GetAttendees() {
+		alarm.properties = append(alarm.properties, attendeeProperty("ATTENDEE", attendee))
+	}
+	alarm.addRawProperties(a.GetRawProperties())
+	return alarm
+}
+
+// builds the VTODO component of the todo
+func (c *Calendar) todoComponent(t *Todo) *component {
+	todo := &component{name: "VTODO"}
+
+	uid := t.GetImportedID()
+	if uid == "" {
+		uid = t.GetID()
+	}
+	if uid == "" {
+		uid = t.GenerateTodoId()
+	}
+	todo.addProperty("UID", uid)
+
+	stamp := t.GetLastModified()
+	if stamp.IsZero() {
+		stamp = t.GetCreated()
+	}
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	todo.addProperty("DTSTAMP", stamp.UTC().Format(IcsFormat))
+
+	if !t.GetStart().IsZero() {
+		todo.properties = append(todo.properties, c.timeProperty("DTSTART", t.GetStart(), t.GetStartTZID(), t.IsWholeDay()))
+	}
+	if !t.GetDue().IsZero() {
+		todo.properties = append(todo.properties, c.timeProperty("DUE", t.GetDue(), t.GetDueTZID(), t.IsWholeDay()))
+	}
+	if !t.GetCompleted().IsZero() {
+		todo.addProperty("COMPLETED", t.GetCompleted().UTC().Format(IcsFormat))
+	}
+	if !t.GetCreated().IsZero() {
+		todo.addProperty("CREATED", t.GetCreated().UTC().Format(IcsFormat))
+	}
+	if !t.GetLastModified().IsZero() {
+		todo.addProperty("LAST-MODIFIED", t.GetLastModified().UTC().Format(IcsFormat))
+	}
+	if t.GetSequence() != 0 {
+		todo.addProperty("SEQUENCE", strconv.Itoa(t.GetSequence()))
+	}
+	if t.GetStatus() != "" {
+		todo.addProperty("STATUS", t.GetStatus())
+	}
+	if t.GetClass() != "" {
+		todo.addProperty("CLASS", t.GetClass())
+	}
+	if t.GetPriority() != 0 {
+		todo.addProperty("PRIORITY", strconv.Itoa(t.GetPriority()))
+	}
+	if t.GetPercentComplete() != 0 {
+		todo.addProperty("PERCENT-COMPLETE", strconv.Itoa(t.GetPercentComplete()))
+	}
+	if t.GetSummary() != "" {
+		todo.addProperty("SUMMARY", escapeText(t.GetSummary()))
+	}
+	if t.GetDescription() != "" {
+		todo.addProperty("DESCRIPTION", escapeText(t.GetDescription()))
+	}
+	if t.GetLocation() != "" {
+		todo.addProperty("LOCATION", escapeText(t.GetLocation()))
+	}
+	for _, uid := range t.GetRelatedTo() {
+		todo.addProperty("RELATED-TO", uid)
+	}
+	if organizer := t.GetOrganizer(); organizer != nil {
+		todo.properties = append(todo.properties, attendeeProperty("ORGANIZER", organizer))
+	}
+	for _, attendee := range t.GetAttendees() {
+		todo.properties = append(todo.properties, attendeeProperty("ATTENDEE", attendee))
+	}
+	todo.addRawProperties(t.GetRawProperties())
+	return todo
+}
+
+// builds the VJOURNAL component of the journal
+func (c *Calendar) journalComponent(j *Journal) *component {
+	journal := &component{name: "VJOURNAL"}
+
+	uid := j.GetImportedID()
+	if uid == "" {
+		uid = j.GetID()
+	}
+	if uid == "" {
+		uid = j.GenerateJournalId()
+	}
+	journal.addProperty("UID", uid)
+
+	stamp := j.GetLastModified()
+	if stamp.IsZero() {
+		stamp = j.GetCreated()
+	}
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	journal.addProperty("DTSTAMP", stamp.UTC().Format(IcsFormat))
+
+	if !j.GetStart().IsZero() {
+		journal.properties = append(journal.properties, c.timeProperty("DTSTART", j.GetStart(), j.GetStartTZID(), j.IsWholeDay()))
+	}
+	if !j.GetCreated().IsZero() {
+		journal.addProperty("CREATED", j.GetCreated().UTC().Format(IcsFormat))
+	}
+	if !j.GetLastModified().IsZero() {
+		journal.addProperty("LAST-MODIFIED", j.GetLastModified().UTC().Format(IcsFormat))
+	}
+	if j.GetSequence() != 0 {
+		journal.addProperty("SEQUENCE", strconv.Itoa(j.GetSequence()))
+	}
+	if j.GetStatus() != "" {
+		journal.addProperty("STATUS", j.GetStatus())
+	}
+	if j.GetClass() != "" {
+		journal.addProperty("CLASS", j.GetClass())
+	}
+	if j.GetSummary() != "" {
+		journal.addProperty("SUMMARY", escapeText(j.GetSummary()))
+	}
+	for _, description := range j.GetDescriptions() {
+		journal.addProperty("DESCRIPTION", escapeText(description))
+	}
+	if len(j.GetCategories()) > 0 {
+		journal.addProperty("CATEGORIES", joinTextList(j.GetCategories()))
+	}
+	for _, attachment := range j.GetAttachments() {
+		// the content of the streamed attachments is not kept
+		if attachment.IsStreamed() {
+			continue
+		}
+		journal.properties = append(journal.properties, attachmentProperty(attachment))
+	}
+	if organizer := j.GetOrganizer(); organizer != nil {
+		journal.properties = append(journal.properties, attendeeProperty("ORGANIZER", organizer))
+	}
+	journal.addRawProperties(j.GetRawProperties())
+	return journal
+}
+
+// builds the VFREEBUSY component of the free/busy , its times are in UTC
+func freeBusyComponent(fb *FreeBusy) *component {
+	comp := &component{name: "VFREEBUSY"}
+
+	uid := fb.GetImportedID()
+	if uid == "" {
+		uid = fb.GenerateFreeBusyId()
+	}
+	comp.addProperty("UID", uid)
+	comp.addProperty("DTSTAMP", time.Now().UTC().Format(IcsFormat))
+	if !fb.GetStart().IsZero() {
+		comp.addProperty("DTSTART", fb.GetStart().UTC().Format(IcsFormat))
+	}
+	if !fb.GetEnd().IsZero() {
+		comp.addProperty("DTEND", fb.GetEnd().UTC().Format(IcsFormat))
+	}
+	if organizer := fb.GetOrganizer(); organizer != nil {
+		comp.properties = append(comp.properties, attendeeProperty("ORGANIZER", organizer))
+	}
+	for _, attendee := range fb.GetAttendees() {
+		comp.properties = append(comp.properties, attendeeProperty("ATTENDEE", attendee))
+	}
+	for _, period := range fb.GetPeriods() {
+		value := period.GetStart().UTC().Format(IcsFormat) + "/" + period.GetEnd().UTC().Format(IcsFormat)
+		comp.addProperty("FREEBUSY", value).addParam("FBTYPE", period.GetFbType())
+	}
+	comp.addRawProperties(fb.GetRawProperties())
+	return comp
+}
+
+// builds ATTACH property
+func attachmentProperty(a *Attachment) *contentLine {
+	prop := &contentLine{name: "ATTACH", value: a.GetURI()}
+	if a.IsBinary() {
+		prop.addParam("ENCODING", "BASE64")
+		prop.addParam("VALUE", "BINARY")
+		prop.value = a.data
+	}
+	if a.GetFmtType() != "" {
+		prop.addParam("FMTTYPE", a.GetFmtType())
+	}
+	if a.GetFilename() != "" {
+		prop.addParam("X-FILENAME", a.GetFilename())
+	}
+	return prop
+}
+
+// builds IMAGE property
+func imageProperty(i *Image) *contentLine {
+	prop := &contentLine{name: "IMAGE", value: i.GetURI()}
+	if i.IsBinary() {
+		prop.addParam("ENCODING", "BASE64")
+		prop.addParam("VALUE", "BINARY")
+		prop.value = i.data
+	} else {
+		prop.addParam("VALUE", "URI")
+	}
+	if i.GetFmtType() != "" {
+		prop.addParam("FMTTYPE", i.GetFmtType())
+	}
+	if i.GetDisplay() != "" {
+		prop.addParam("DISPLAY", i.GetDisplay())
+	}
+	return prop
+}
+
+// builds CONFERENCE property
+func conferenceProperty(c *Conference) *contentLine {
+	prop := &contentLine{name: "CONFERENCE", value: c.GetURI()}
+	prop.addParam("VALUE", "URI")
+	if len(c.GetFeatures()) > 0 {
+		prop.addParam("FEATURE", c.GetFeatures()...)
+	}
+	if c.GetLabel() != "" {
+		prop.addParam("LABEL", c.GetLabel())
+	}
+	return prop
+}
+
+// builds DATE or DATE-TIME property. Times with TZID are written in that time zone ,
+// the floating ones in the calendar time zone stay floating and the others are written in UTC
+func (c *Calendar) timeProperty(name string, t time.Time, tzID string, wholeDay bool) *contentLine {
+	prop := &contentLine{name: name}
+	switch {
+	case wholeDay:
+		prop.addParam("VALUE", "DATE")
+		prop.value = t.Format(IcsFormatWholeDay)
+	case tzID != "":
+		if loc, err := c.GetTimezoneByID(tzID); err == nil {
+			t = t.In(loc)
+		}
+		prop.addParam("TZID", tzID)
+		prop.value = t.Format(icsFormatLocal)
+	case t.Location() == c.location():
+		prop.value = t.Format(icsFormatLocal)
+	default:
+		prop.value = t.UTC().Format(IcsFormat)
+	}
+	return prop
+}
+
+// builds ATTENDEE or ORGANIZER property
+func attendeeProperty(name string, a *Attendee) *contentLine {
+	prop := &contentLine{name: name, value: calAddress(a.GetEmail())}
+	if a.GetName() != "" {
+		prop.addParam("CN", a.GetName())
+	}
+	if a.GetRole() != "" {
+		prop.addParam("ROLE", string(a.GetRole()))
+	}
+	if a.GetStatus() != "" {
+		prop.addParam("PARTSTAT", string(a.GetStatus()))
+	}
+	if a.GetType() != "" {
+		prop.addParam("CUTYPE", string(a.GetType()))
+	}
+	if a.GetRSVP() {
+		prop.addParam("RSVP", "TRUE")
+	}
+	if len(a.GetDelegatedTo()) > 0 {
+		prop.addParam("DELEGATED-TO", calAddresses(a.GetDelegatedTo())...)
+	}
+	if len(a.GetDelegatedFrom()) > 0 {
+		prop.addParam("DELEGATED-FROM", calAddresses(a.GetDelegatedFrom())...)
+	}
+	if len(a.GetMember()) > 0 {
+		prop.addParam("MEMBER", calAddresses(a.GetMember())...)
+	}
+	if a.GetSentBy() != "" {
+		prop.addParam("SENT-BY", calAddress(a.GetSentBy()))
+	}
+	if a.GetDir() != "" {
+		prop.addParam("DIR", a.GetDir())
+	}
+	if a.GetLanguage() != "" {
+		prop.addParam("LANGUAGE", a.GetLanguage())
+	}
+	return prop
+}
+
+// adds mailto: to the emails , the addresses with other schemes are kept
+func calAddresses(emails []string) []string {
+	addresses := make([]string, len(emails))
+	for i, email := range emails {
+		addresses[i] = calAddress(email)
+	}
+	return addresses
+}
+
+// returns the CAL-ADDRESS URI of the address , mailto: is added only when it has no scheme
+// like urn:uuid: , sip: or tel:
+func calAddress(address string) string {
+	if hasURIScheme(address) {
+		return address
+	}
+	return "mailto:" + address
+}
+
+// reports if the value starts with URI scheme , letters followed by letters , digits , + , - or . and colon
+func hasURIScheme(value string) bool {
+	for i, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		case r == ':':
+			return i > 0
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// adds property with the given name and value to the component
+func (c *component) addProperty(name, value string) *contentLine {
+	prop := &contentLine{name: name, value: value}
+	c.properties = append(c.properties, prop)
+	return prop
+}
+
+// adds TEXT property with the value and the parameters of the first text , then the other texts
+// which are the same property in other languages
+func (c *component) addTextProperties(name, value string, texts []*Text) {
+	if value == "" && len(texts) == 0 {
+		return
+	}
+	textProperty := func(value string, t *Text) {
+		prop := c.addProperty(name, escapeText(value))
+		if t == nil {
+			return
+		}
+		if t.GetLanguage() != "" {
+			prop.addParam("LANGUAGE", t.GetLanguage())
+		}
+		if t.GetAltRep() != "" {
+			prop.addParam("ALTREP", t.GetAltRep())
+		}
+	}
+
+	if len(texts) == 0 {
+		textProperty(value, nil)
+		return
+	}
+	textProperty(value, texts[0])
+	for _, t := range texts[1:] {
+		textProperty(t.GetValue(), t)
+	}
+}
+
+// adds the raw properties that are not written from the fields of the component
+func (c *component) addRawProperties(properties []*Property) {
+	for _, prop := range properties {
+		if !containsString(writtenProperties[c.name], prop.GetName()) {
+			c.properties = append(c.properties, prop.contentLine())
+		}
+	}
+}
+
+// adds parameter with the given values to the content line
+func (cl *contentLine) addParam(name string, values ...string) *contentLine {
+	cl.params = append(cl.params, &contentParam{name: name, values: values})
+	return cl
+}
+
+// returns the content line as RFC 5545 text , not folded
+func (cl *contentLine) String() string {
+	var sb strings.Builder
+	sb.WriteString(cl.name)
+	for _, param := range cl.params {
+		sb.WriteByte(';')
+		sb.WriteString(param.name)
+		sb.WriteByte('=')
+		for i, value := range param.values {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(encodeParamValue(value))
+		}
+	}
+	sb.WriteByte(':')
+	sb.WriteString(cl.value)
+	return sb.String()
+}
+
+// encodes the parameter value with the RFC 6868 caret escapes , quoting it when needed
+func encodeParamValue(value string) string {
+	value = strings.NewReplacer("^", "^^", "\n", "^n", "\"", "^'").Replace(value)
+	if strings.ContainsAny(value, ":;,") {
+		return "\"" + value + "\""
+	}
+	return value
+}
+
+// escapes the TEXT value
+func escapeText(value string) string {
+	return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n").Replace(value)
+}
+
+// escapes and joins the TEXT values with commas
+func joinTextList(values []string) string {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = escapeText(value)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// folds the line to lines of at most 75 octets , without splitting UTF-8 chars
+func foldLine(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+	var sb strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of the continuation line counts too
+		limit = maxLineOctets - 1
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
+
+// icsWriter writes folded content lines , counting the written bytes and keeping the first error
+type icsWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// writes a single content line
+func (iw *icsWriter) line(line string) {
+	if iw.err != nil {
+		return
+	}
+	n, err := io.WriteString(iw.w, foldLine(line)+"\r\n")
+	iw.n += int64(n)
+	iw.err = err
+}
+
+// writes the component with its properties and nested components
+func (iw *icsWriter) component(c *component) {
+	iw.line("BEGIN:" + c.name)
+	for _, prop := range c.properties {
+		iw.line(prop.String())
+	}
+	for _, comp := range c.components {
+		iw.component(comp)
+	}
+	iw.line("END:" + c.name)
+}
+
+// checks if the string is in the slice
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
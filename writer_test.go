@@ -0,0 +1,239 @@
+package ics
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parses the ics text and returns the only calendar in it
+func loadSingleCalendar(t *testing.T, data []byte, options ...Option) *Calendar {
+	parser := New(options...)
+	if err := parser.LoadReader(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	calendars, _ := parser.GetCalendars()
+	if len(calendars) != 1 {
+		t.Fatalf("Expected 1 calendar, found %d calendars", len(calendars))
+	}
+	return calendars[0]
+}
+
+// reports the fields that differ between the events
+func compareEvents(t *testing.T, expected, got *Event) {
+	id := expected.GetImportedID()
+	sameTimes := func(a, b []time.Time) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !a[i].Equal(b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	sameAttendee := func(a, b *Attendee) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return reflect.DeepEqual(a, b)
+	}
+
+	if !got.GetStart().Equal(expected.GetStart()) || !got.GetEnd().Equal(expected.GetEnd()) {
+		t.Errorf("%s: expected %s - %s, got %s - %s", id, expected.GetStart(), expected.GetEnd(), got.GetStart(), got.GetEnd())
+	}
+	if got.GetStartTZID() != expected.GetStartTZID() || got.GetEndTZID() != expected.GetEndTZID() {
+		t.Errorf("%s: expected TZID %q %q, got %q %q", id, expected.GetStartTZID(), expected.GetEndTZID(), got.GetStartTZID(), got.GetEndTZID())
+	}
+	if !got.GetCreated().Equal(expected.GetCreated()) || !got.GetLastModified().Equal(expected.GetLastModified()) {
+		t.Errorf("%s: expected created and modified %s %s, got %s %s", id, expected.GetCreated(), expected.GetLastModified(), got.GetCreated(), got.GetLastModified())
+	}
+	if got.GetStatus() != expected.GetStatus() || got.GetClass() != expected.GetClass() || got.GetSequence() != expected.GetSequence() {
+		t.Errorf("%s: expected status, class and sequence %q %q %d, got %q %q %d", id, expected.GetStatus(), expected.GetClass(), expected.GetSequence(), got.GetStatus(), got.GetClass(), got.GetSequence())
+	}
+	if got.GetSummary() != expected.GetSummary() || got.GetDescription() != expected.GetDescription() || got.GetLocation() != expected.GetLocation() {
+		t.Errorf("%s: expected texts %q %q %q, got %q %q %q", id, expected.GetSummary(), expected.GetDescription(), expected.GetLocation(), got.GetSummary(), got.GetDescription(), got.GetLocation())
+	}
+	if (got.GetGeo() == nil) != (expected.GetGeo() == nil) || got.GetGeo() != nil && *got.GetGeo() != *expected.GetGeo() {
+		t.Errorf("%s: expected geo %v, got %v", id, expected.GetGeo(), got.GetGeo())
+	}
+	if got.GetRRule() != expected.GetRRule() || !sameTimes(got.GetExDates(), expected.GetExDates()) || !sameTimes(got.GetRDates(), expected.GetRDates()) || !got.GetRecurrenceID().Equal(expected.GetRecurrenceID()) {
+		t.Errorf("%s: expected recurrence %q %v %v %s, got %q %v %v %s", id, expected.GetRRule(), expected.GetExDates(), expected.GetRDates(), expected.GetRecurrenceID(), got.GetRRule(), got.GetExDates(), got.GetRDates(), got.GetRecurrenceID())
+	}
+	if got.IsWholeDay() != expected.IsWholeDay() {
+		t.Errorf("%s: expected whole day %t, got %t", id, expected.IsWholeDay(), got.IsWholeDay())
+	}
+	if !sameAttendee(got.GetOrganizer(), expected.GetOrganizer()) {
+		t.Errorf("%s: expected organizer %v, got %v", id, expected.GetOrganizer(), got.GetOrganizer())
+	}
+	if len(got.GetAttendees()) != len(expected.GetAttendees()) {
+		t.Errorf("%s: expected %d attendees, got %d", id, len(expected.GetAttendees()), len(got.GetAttendees()))
+		return
+	}
+	for i := range got.GetAttendees() {
+		if !sameAttendee(got.GetAttendees()[i], expected.GetAttendees()[i]) {
+			t.Errorf("%s: expected attendee %v, got %v", id, expected.GetAttendees()[i], got.GetAttendees()[i])
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	for _, file := range []string{"testCalendars/2eventsCal.ics", "testCalendars/outlook.ics", "testCalendars/multiday.ics", "testCalendars/3eventsNoAttendee.ics"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s ( %s )", file, err)
+		}
+		original := loadSingleCalendar(t, data)
+
+		written, err := original.MarshalICS()
+		if err != nil {
+			t.Fatalf("%s: failed to write ( %s )", file, err)
+		}
+		parsed := loadSingleCalendar(t, written)
+
+		if parsed.GetName() != original.GetName() || parsed.GetDesc() != original.GetDesc() || parsed.GetVersion() != original.GetVersion() ||
+			original.GetProdID() == "" || parsed.GetProdID() != original.GetProdID() {
+			t.Errorf("%s: expected calendar %s, got %s", file, original, parsed)
+		}
+		if len(parsed.GetEvents()) != len(original.GetEvents()) {
+			t.Errorf("%s: expected %d events, got %d", file, len(original.GetEvents()), len(parsed.GetEvents()))
+			continue
+		}
+		for i := range original.GetEvents() {
+			compareEvents(t, original.GetEvents()[i], parsed.GetEvents()[i])
+		}
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	sofia, err := time.LoadLocation("Europe/Sofia")
+	if err != nil {
+		t.Skipf("No time zone data ( %s )", err)
+	}
+	cal := NewCalendar()
+	cal.SetName("Team, Sofia")
+
+	meeting := NewEvent()
+	meeting.SetImportedID("meeting")
+	meeting.SetStart(time.Date(2019, 7, 1, 9, 0, 0, 0, sofia))
+	meeting.SetEnd(time.Date(2019, 7, 1, 10, 0, 0, 0, sofia))
+	meeting.SetStartTZID("Europe/Sofia")
+	meeting.SetEndTZID("Europe/Sofia")
+	meeting.SetSummary("Planning; Q3, \"all\"")
+	meeting.SetDescription(strings.Repeat("Ünïcödé agenda line ", 10) + "\nsecond line")
+	meeting.SetAttendee(NewAttendee().SetName("Doe, John").SetEmail("john@example.com").SetRole("REQ-PARTICIPANT"))
+	cal.SetEvent(meeting)
+
+	holiday := NewEvent()
+	holiday.SetImportedID("holiday")
+	holiday.SetStart(time.Date(2019, 12, 24, 0, 0, 0, 0, time.UTC))
+	holiday.SetEnd(time.Date(2019, 12, 27, 0, 0, 0, 0, time.UTC))
+	holiday.SetWholeDayEvent(true)
+	cal.SetEvent(holiday)
+
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	text := string(written)
+
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets %q", line)
+		}
+	}
+	for _, expected := range []string{
+		"PRODID:-//PuloV//ics-golang//EN\r\n",
+		"X-WR-CALNAME:Team\\, Sofia\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Sofia\r\n",
+		"DTSTART;TZID=Europe/Sofia:20190701T090000\r\n",
+		"SUMMARY:Planning\\; Q3\\, \"all\"\r\n",
+		"ATTENDEE;CN=\"Doe, John\";ROLE=REQ-PARTICIPANT:mailto:john@example.com\r\n",
+		"DTSTART;VALUE=DATE:20191224\r\n",
+		"DTEND;VALUE=DATE:20191227\r\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in\n%s", expected, text)
+		}
+	}
+
+	parsed := loadSingleCalendar(t, written)
+	if parsed.GetName() != cal.GetName() {
+		t.Errorf("Expected name %q, got %q", cal.GetName(), parsed.GetName())
+	}
+	for i := range cal.GetEvents() {
+		compareEvents(t, cal.GetEvents()[i], parsed.GetEvents()[i])
+	}
+}
+
+func TestWriteMidnightTimes(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:maintenance\r\n"+
+		"DTSTART:20190604T000000Z\r\n"+
+		"DTEND:20190605T000000Z\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:holiday\r\n"+
+		"DTSTART;VALUE=DATE:20190606\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VTODO\r\n"+
+		"UID:backup\r\n"+
+		"DUE:20190607T000000Z\r\n"+
+		"END:VTODO\r\n"+
+		"END:VCALENDAR\r\n"))
+
+	maintenance, _ := cal.GetEventByImportedID("maintenance")
+	holiday, _ := cal.GetEventByImportedID("holiday")
+	backup, _ := cal.GetTodoByImportedID("backup")
+	if maintenance.IsWholeDay() || !holiday.IsWholeDay() || backup.IsWholeDay() {
+		t.Errorf("Expected whole day only for the DATE value")
+	}
+
+	// the midnight DATE-TIME values are written with their times
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	for _, expected := range []string{"DTSTART:20190604T000000Z\r\n", "DTEND:20190605T000000Z\r\n", "DTSTART;VALUE=DATE:20190606\r\n", "DUE:20190607T000000Z\r\n"} {
+		if !strings.Contains(string(written), expected) {
+			t.Errorf("Expected %q in\n%s", expected, written)
+		}
+	}
+}
+
+func TestWriteSkipsGeneratedOccurrences(t *testing.T) {
+	cal := loadSingleCalendar(t, []byte(recurringStandup), WithRecurrenceExpansion(true))
+	written, err := cal.MarshalICS()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	parsed := loadSingleCalendar(t, written, WithRecurrenceExpansion(true))
+	if len(parsed.GetEvents()) != len(cal.GetEvents()) {
+		t.Errorf("Expected the written calendar to expand to %d events, got %d", len(cal.GetEvents()), len(parsed.GetEvents()))
+	}
+	if strings.Count(string(written), "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected only the repeating event and its override to be written, got\n%s", written)
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("ж", 100)
+	folded := foldLine(line)
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("Line longer than 75 octets %q", part)
+		}
+	}
+	unfolded, err := newLexer(strings.NewReader(folded + "\r\n")).next()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if unfolded.String() != line {
+		t.Errorf("Expected %q after unfolding, got %q", line, unfolded.String())
+	}
+}